@@ -0,0 +1,37 @@
+// Command tesla-mock-server runs a fake Tesla auth, owner-api and tasks
+// server for offline testing of the application.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/mockserver"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8787", "address to listen on")
+	scenarioFile := flag.String("scenario", "", "JSON scenario file (default: bundled scenario)")
+	tokenLifetime := flag.Duration("token-lifetime", 8*time.Hour, "validity of issued access tokens")
+	flag.Parse()
+
+	scenario := mockserver.DefaultScenario()
+	if *scenarioFile != "" {
+		var err error
+		scenario, err = mockserver.LoadScenario(*scenarioFile)
+		if err != nil {
+			log.Fatalf("Error loading scenario: %v", err)
+		}
+	}
+
+	server := mockserver.New(scenario)
+	server.TokenLifetime = *tokenLifetime
+
+	fmt.Printf("Mock Tesla server listening on http://%s\n", *addr)
+	fmt.Printf("Point the application at it with:\n\n  export TESLA_API_BASE_URL=http://%s\n\n", *addr)
+
+	log.Fatal(http.ListenAndServe(*addr, server))
+}
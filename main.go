@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 
@@ -37,7 +38,10 @@ func main() {
 	
 	w.SetMaster()
 	
-	teslaAuth := tesla.NewTeslaAuth()
+	accounts, err := tesla.LoadAccounts()
+	if err != nil {
+		log.Printf("Error loading accounts: %v", err)
+	}
 	
 	// Sol alt köşe için versiyon etiketi
 	versionLabel := widget.NewLabelWithStyle(version.String(), fyne.TextAlignLeading, fyne.TextStyle{})
@@ -68,35 +72,48 @@ func main() {
 	// Check for updates
 	go checkForUpdates(w)
 	
-	var showAuthScreenFunc func()
+	var showAuthScreenFunc func(account tesla.Account, canCancel bool)
 	var showOrdersScreenFunc func()
 	
 	showOrdersScreenFunc = func() {
-		ordersScreen := gui.NewOrdersScreen(a, w, teslaAuth, func() { // onLogout callback
-			showAuthScreenFunc()
-		})
+		ordersScreen := gui.NewOrdersScreen(a, w, accounts,
+			func(account tesla.Account) { // onLogout callback
+				showAuthScreenFunc(account, len(accounts.Accounts) > 1)
+			},
+			func(account tesla.Account) { // onAddAccount callback
+				showAuthScreenFunc(account, true)
+			},
+		)
 		screenContent := ordersScreen.GetContent()
 		setContentWithVersion(screenContent)
 		ordersScreen.PerformInitialSetup() // Perform initial setup after content is set
 	}
 	
-	showAuthScreenFunc = func() {
+	showAuthScreenFunc = func(account tesla.Account, canCancel bool) {
+		teslaAuth := account.NewTeslaAuth()
 		authScreen := gui.NewAuthScreen(a, w, teslaAuth, func() { // onComplete callback
 			showOrdersScreenFunc()
 		})
+		if canCancel {
+			authScreen.SetOnCancel(showOrdersScreenFunc)
+		}
 		
-		if err := teslaAuth.LoadTokensFromFile(); err == nil && teslaAuth.IsTokenValid() {
-			log.Println("Token loaded and valid, showing orders screen.")
+		if err := teslaAuth.LoadTokens(); err != nil && !errors.Is(err, tesla.ErrNoTokens) {
+			log.Printf("Could not load tokens for account %s: %v", account.Name, err)
+		} else if err == nil && (teslaAuth.IsTokenValid() || teslaAuth.RefreshToken != "") {
+			// An expired access token is refreshed by the orders screen in the
+			// background; it returns here if the refresh token is rejected.
+			log.Printf("Token loaded for account %s, showing orders screen.", account.Name)
 			showOrdersScreenFunc()
 			return
 		}
-		log.Println("Token not found or invalid, showing auth screen.")
+		log.Printf("Token not found or invalid for account %s, showing auth screen.", account.Name)
 		screenContent := authScreen.GetContent()
 		setContentWithVersion(screenContent)
 		authScreen.PerformInitialSetup() // Perform initial setup after content is set
 	}
 	
-	showAuthScreenFunc()
+	showAuthScreenFunc(accounts.ActiveAccount(), false)
 	
 	a.Run()
 }
@@ -0,0 +1,749 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/notify"
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+	"github.com/tgezginis/tesla-tracking-app/pkg/watch"
+)
+
+// Exit codes returned by Run so the command can be used from scripts.
+const (
+	ExitOK            = 0
+	ExitError         = 1
+	ExitUsage         = 2
+	ExitLoginRequired = 3
+	ExitChanges       = 4
+)
+
+const usage = `Usage: tesla-cli <command> [flags]
+
+Commands:
+  status             List orders with their current status
+  details <RN>       Show all known fields of a single order
+  diff               Fetch orders and report changes since the last saved snapshot
+  history [RN]       Show the recorded status, VIN and delivery timeline
+  login              Log in with a Tesla account and save the tokens
+  watch              Poll orders in the background and send notifications on changes
+  accounts           List accounts; 'accounts add|remove|use <name>' manages them,
+                     'accounts market <name> <country|locale|auto>' sets the market
+  stores             List delivery centers; 'stores show <id>' shows one,
+                     'stores update <file|url>' merges a JSON or CSV list
+
+Every command accepts -account <name> to act on another than the active account.
+
+Run 'tesla-cli <command> -h' for the flags of a command.
+
+Exit codes:
+  0  success, no changes
+  1  error
+  2  invalid usage
+  3  login required
+  4  diff or watch -once found changes
+`
+
+// statusColumns are the ExtractOrderInfo fields shown by the status table,
+// plus the derived lifecycle stage.
+var statusColumns = []string{"OrderID", "Model", "Status", "Stage", "VIN", "DeliveryWindow", "ETAToDeliveryCenter"}
+
+// statusRow is an order of the status table. Stale orders are listed with
+// their saved details.
+type statusRow struct {
+	info  map[string]string
+	stale bool
+}
+
+type cli struct {
+	ctx         context.Context
+	stdin       io.Reader
+	stdout      io.Writer
+	stderr      io.Writer
+	accountName string
+}
+
+// Run executes the command line given in args and returns the process exit
+// code. Cancelling ctx aborts requests in flight and stops watch.
+func Run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &cli{ctx: ctx, stdin: stdin, stdout: stdout, stderr: stderr}
+
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "status":
+		return c.status(args[1:])
+	case "details":
+		return c.details(args[1:])
+	case "diff":
+		return c.diff(args[1:])
+	case "history":
+		return c.history(args[1:])
+	case "login":
+		return c.login(args[1:])
+	case "watch":
+		return c.watch(args[1:])
+	case "accounts":
+		return c.accounts(args[1:])
+	case "stores":
+		return c.stores(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return ExitOK
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return ExitUsage
+	}
+}
+
+func (c *cli) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.StringVar(&c.accountName, "account", "", "account to use (default: the active account)")
+	return fs
+}
+
+// account returns the account selected with -account, or the active one.
+func (c *cli) account() (tesla.Account, int) {
+	store, err := tesla.LoadAccounts()
+	if err != nil {
+		return tesla.Account{}, c.fail(err)
+	}
+
+	if c.accountName == "" {
+		return store.ActiveAccount(), ExitOK
+	}
+
+	account, ok := store.Get(c.accountName)
+	if !ok {
+		fmt.Fprintf(c.stderr, "account %q not found, add it with 'tesla-cli accounts add %s'\n", c.accountName, c.accountName)
+		return tesla.Account{}, ExitUsage
+	}
+	return account, ExitOK
+}
+
+func (c *cli) status(args []string) int {
+	fs := c.newFlagSet("status")
+	asJSON := fs.Bool("json", false, "print orders as JSON")
+	cached := fs.Bool("cached", false, "use the saved orders instead of querying Tesla")
+	all := fs.Bool("all", false, "list the orders of all accounts")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	var accounts []tesla.Account
+	if *all {
+		store, err := tesla.LoadAccounts()
+		if err != nil {
+			return c.fail(err)
+		}
+		accounts = store.Accounts
+	} else {
+		account, code := c.account()
+		if code != ExitOK {
+			return code
+		}
+		accounts = []tesla.Account{account}
+	}
+
+	var rows []statusRow
+	for _, account := range accounts {
+		manager, orders, code := c.loadOrders(account, *cached)
+		if code == ExitLoginRequired && *all {
+			continue
+		}
+		if code != ExitOK {
+			return code
+		}
+
+		for _, order := range orders {
+			info := manager.ExtractOrderInfo(order)
+			info["Account"] = account.Name
+			info["Stage"] = string(tesla.OrderStage(order, time.Now()))
+			rows = append(rows, statusRow{info: info, stale: order.Stale})
+		}
+	}
+
+	if *asJSON {
+		out := make([]map[string]interface{}, 0, len(rows))
+		for _, row := range rows {
+			fields := map[string]interface{}{"Stale": row.stale}
+			for key, value := range row.info {
+				fields[key] = value
+			}
+			out = append(out, fields)
+		}
+		return c.printJSON(out)
+	}
+
+	columns := statusColumns
+	if *all {
+		columns = append([]string{"Account"}, statusColumns...)
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(columns, "\t"))
+	for _, row := range rows {
+		cells := make([]string, 0, len(columns))
+		for _, column := range columns {
+			value := row.info[column]
+			if column == "Status" && row.stale {
+				value += " (stale)"
+			}
+			cells = append(cells, valueOrDash(value))
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return c.fail(err)
+	}
+
+	return ExitOK
+}
+
+func (c *cli) details(args []string) int {
+	fs := c.newFlagSet("details")
+	asJSON := fs.Bool("json", false, "print details as JSON")
+	cached := fs.Bool("cached", false, "use the saved orders instead of querying Tesla")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(c.stderr, "usage: tesla-cli details [flags] <reference number>")
+		return ExitUsage
+	}
+	referenceNumber := fs.Arg(0)
+
+	account, code := c.account()
+	if code != ExitOK {
+		return code
+	}
+
+	manager, orders, code := c.loadOrders(account, *cached)
+	if code != ExitOK {
+		return code
+	}
+
+	for _, order := range orders {
+		if order.Order.ReferenceNumber != referenceNumber {
+			continue
+		}
+
+		info := manager.ExtractOrderInfo(order)
+		if *asJSON {
+			return c.printJSON(info)
+		}
+
+		keys := make([]string, 0, len(info))
+		for key := range info {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+		for _, key := range keys {
+			fmt.Fprintf(w, "%s\t%s\n", key, valueOrDash(info[key]))
+		}
+		if err := w.Flush(); err != nil {
+			return c.fail(err)
+		}
+		return ExitOK
+	}
+
+	fmt.Fprintf(c.stderr, "order %s not found\n", referenceNumber)
+	return ExitError
+}
+
+func (c *cli) diff(args []string) int {
+	fs := c.newFlagSet("diff")
+	asJSON := fs.Bool("json", false, "print changes as JSON")
+	noSave := fs.Bool("no-save", false, "do not replace the saved orders with the fetched ones")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	account, code := c.account()
+	if code != ExitOK {
+		return code
+	}
+
+	manager, newOrders, code := c.loadOrders(account, false)
+	if code != ExitOK {
+		return code
+	}
+
+	oldOrders, err := manager.LoadOrdersFromFile()
+	if err != nil {
+		return c.fail(err)
+	}
+
+	changes := []tesla.Change{}
+	if len(oldOrders) > 0 {
+		changes = manager.CompareOrders(oldOrders, newOrders)
+	}
+
+	if !*noSave {
+		if err := manager.SaveOrdersToFile(newOrders); err != nil {
+			return c.fail(err)
+		}
+	}
+
+	if *asJSON {
+		if code := c.printJSON(changes); code != ExitOK {
+			return code
+		}
+	} else {
+		for _, change := range changes {
+			fmt.Fprintln(c.stdout, change)
+		}
+	}
+
+	if len(changes) > 0 {
+		return ExitChanges
+	}
+	return ExitOK
+}
+
+func (c *cli) history(args []string) int {
+	fs := c.newFlagSet("history")
+	asJSON := fs.Bool("json", false, "print history as JSON")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if fs.NArg() > 1 {
+		fmt.Fprintln(c.stderr, "usage: tesla-cli history [flags] [reference number]")
+		return ExitUsage
+	}
+
+	account, code := c.account()
+	if code != ExitOK {
+		return code
+	}
+
+	manager := account.NewOrderManager(account.NewTeslaAuth())
+	entries, err := manager.LoadHistory(fs.Arg(0))
+	if err != nil {
+		return c.fail(err)
+	}
+
+	if *asJSON {
+		return c.printJSON(entries)
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tORDER\tFIELD\tOLD\tNEW")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.Time.Local().Format("2006-01-02 15:04"),
+			entry.ReferenceNumber, entry.Field, valueOrDash(entry.OldValue), valueOrDash(entry.NewValue))
+	}
+	if err := w.Flush(); err != nil {
+		return c.fail(err)
+	}
+
+	return ExitOK
+}
+
+func (c *cli) login(args []string) int {
+	fs := c.newFlagSet("login")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	account, code := c.account()
+	if code != ExitOK {
+		return code
+	}
+
+	auth := account.NewTeslaAuth()
+	authURL, err := auth.GetAuthURL()
+	if err != nil {
+		return c.fail(err)
+	}
+
+	// With a loopback redirect URI the browser hands the code back by
+	// itself; pasting the URL keeps working either way.
+	var server *tesla.CallbackServer
+	if tesla.IsLoopbackRedirect(auth.Endpoints.RedirectURI) {
+		if server, err = auth.ListenForCallback(); err != nil {
+			fmt.Fprintf(c.stderr, "warning: %v\n", err)
+		} else {
+			defer server.Close()
+		}
+	}
+
+	fmt.Fprintf(c.stdout, "Logging in account %s.\n", account.Name)
+	fmt.Fprintln(c.stdout, "Open the following URL in a browser and log in with your Tesla account:")
+	fmt.Fprintln(c.stdout)
+	fmt.Fprintln(c.stdout, authURL)
+	fmt.Fprintln(c.stdout)
+	if server != nil {
+		fmt.Fprint(c.stdout, "Waiting for the browser to return, or paste the URL from the address bar here: ")
+	} else {
+		fmt.Fprint(c.stdout, "After being redirected, paste the URL from the address bar here: ")
+	}
+
+	authCode, err := c.readAuthCode(auth, server)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	if err := auth.ExchangeCodeForTokens(c.ctx, authCode); err != nil {
+		return c.fail(err)
+	}
+
+	if err := auth.SaveTokens(); err != nil {
+		return c.fail(err)
+	}
+
+	if profile := account.Profile(); profile.Label() != "" {
+		fmt.Fprintf(c.stdout, "Logged in as %s.\n", profile.Label())
+	} else {
+		fmt.Fprintln(c.stdout, "Login successful.")
+	}
+	return ExitOK
+}
+
+// readAuthCode returns the code of the callback URL pasted on stdin or, if
+// server is not nil, received by it, whichever comes first.
+func (c *cli) readAuthCode(auth *tesla.TeslaAuth, server *tesla.CallbackServer) (string, error) {
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 2)
+
+	go func() {
+		line, err := bufio.NewReader(c.stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			results <- result{err: err}
+			return
+		}
+		if strings.TrimSpace(line) == "" && server != nil {
+			// Nothing pasted; leave it to the callback.
+			return
+		}
+		code, err := auth.ParseCallback(line)
+		results <- result{code, err}
+	}()
+
+	if server != nil {
+		go func() {
+			code, err := server.Wait(c.ctx)
+			results <- result{code, err}
+		}()
+	}
+
+	select {
+	case r := <-results:
+		return r.code, r.err
+	case <-c.ctx.Done():
+		return "", c.ctx.Err()
+	}
+}
+
+func (c *cli) watch(args []string) int {
+	fs := c.newFlagSet("watch")
+	configPath := fs.String("config", watch.ConfigFile(), "path of the watch configuration file")
+	once := fs.Bool("once", false, "poll a single time and exit")
+	initConfig := fs.Bool("init", false, "write a default configuration file and exit")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	if *initConfig {
+		if _, err := os.Stat(*configPath); err == nil {
+			fmt.Fprintf(c.stderr, "%s already exists\n", *configPath)
+			return ExitError
+		}
+		if err := watch.SaveConfig(*configPath, watch.DefaultConfig()); err != nil {
+			return c.fail(err)
+		}
+		fmt.Fprintf(c.stdout, "Wrote %s\n", *configPath)
+		return ExitOK
+	}
+
+	cfg, err := watch.LoadConfig(*configPath)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	notifiers, err := notify.FromConfig(cfg.Notifiers)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	store, err := tesla.LoadAccounts()
+	if err != nil {
+		return c.fail(err)
+	}
+
+	accounts := store.Accounts
+	if c.accountName != "" {
+		account, code := c.account()
+		if code != ExitOK {
+			return code
+		}
+		accounts = []tesla.Account{account}
+	}
+
+	targets := []watch.Target{}
+	for _, account := range accounts {
+		auth := account.NewTeslaAuth()
+		if err := auth.LoadTokens(); err != nil {
+			if !errors.Is(err, tesla.ErrNoTokens) {
+				fmt.Fprintf(c.stderr, "skipping account %s: %v\n", account.Name, err)
+			}
+			continue
+		}
+		targets = append(targets, watch.Target{Account: account.Name, Manager: account.NewOrderManager(auth)})
+	}
+	if len(targets) == 0 {
+		fmt.Fprintln(c.stderr, "not logged in, run 'tesla-cli login' first")
+		return ExitLoginRequired
+	}
+
+	watcher := watch.NewWatcher(targets, time.Duration(cfg.Interval), notifiers)
+
+	if *once {
+		changes, err := watcher.Poll(c.ctx)
+		if err != nil {
+			return c.fail(err)
+		}
+		if len(changes) > 0 {
+			return ExitChanges
+		}
+		return ExitOK
+	}
+
+	if err := watcher.Run(c.ctx); err != nil {
+		return c.fail(err)
+	}
+	return ExitOK
+}
+
+func (c *cli) accounts(args []string) int {
+	fs := c.newFlagSet("accounts")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	store, err := tesla.LoadAccounts()
+	if err != nil {
+		return c.fail(err)
+	}
+
+	if fs.NArg() == 0 {
+		for _, account := range store.Accounts {
+			marker := " "
+			if account.Name == store.Active {
+				marker = "*"
+			}
+			fmt.Fprintf(c.stdout, "%s %-20s %-8s %s\n", marker, account.Name, account.Market, account.Profile().Label())
+		}
+		return ExitOK
+	}
+
+	wantArgs := 2
+	if fs.Arg(0) == "market" {
+		wantArgs = 3
+	}
+	if fs.NArg() != wantArgs {
+		fmt.Fprintln(c.stderr, "usage: tesla-cli accounts [add|remove|use <name> | market <name> <country|locale|auto>]")
+		return ExitUsage
+	}
+
+	name := fs.Arg(1)
+	switch fs.Arg(0) {
+	case "add":
+		if _, err := store.Add(name); err != nil {
+			return c.fail(err)
+		}
+		fmt.Fprintf(c.stdout, "Added account %s, log in with 'tesla-cli login -account %s'\n", name, name)
+	case "remove":
+		if err := store.Remove(name); err != nil {
+			return c.fail(err)
+		}
+		fmt.Fprintf(c.stdout, "Removed account %s\n", name)
+	case "use":
+		if err := store.SetActive(name); err != nil {
+			return c.fail(err)
+		}
+		fmt.Fprintf(c.stdout, "Active account is now %s\n", name)
+	case "market":
+		market, err := tesla.ParseMarket(fs.Arg(2))
+		if err != nil {
+			fmt.Fprintln(c.stderr, err)
+			return ExitUsage
+		}
+		if err := store.SetMarket(name, market); err != nil {
+			return c.fail(err)
+		}
+		fmt.Fprintf(c.stdout, "Market of account %s is now %s\n", name, market)
+	default:
+		fmt.Fprintf(c.stderr, "unknown accounts command %q\n", fs.Arg(0))
+		return ExitUsage
+	}
+
+	return ExitOK
+}
+
+func (c *cli) stores(args []string) int {
+	fs := c.newFlagSet("stores")
+	country := fs.String("country", "", "only list the stores of this country code")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	directory := tesla.Stores()
+
+	switch {
+	case fs.NArg() == 0:
+		w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tCOUNTRY\tCITY\tNAME")
+		for _, store := range directory.Stores {
+			if *country != "" && !strings.EqualFold(store.Country, *country) {
+				continue
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", store.ID, store.Country, store.City, store.Name)
+		}
+		w.Flush()
+		return ExitOK
+
+	case fs.NArg() == 2 && fs.Arg(0) == "show":
+		id, err := strconv.Atoi(fs.Arg(1))
+		if err != nil {
+			fmt.Fprintf(c.stderr, "invalid store id %q\n", fs.Arg(1))
+			return ExitUsage
+		}
+		store, ok := directory.Lookup(id)
+		if !ok {
+			return c.fail(fmt.Errorf("no store with id %d", id))
+		}
+		return c.printJSON(store)
+
+	case fs.NArg() == 2 && fs.Arg(0) == "update":
+		data, err := c.readSource(fs.Arg(1))
+		if err != nil {
+			return c.fail(err)
+		}
+		update, err := tesla.ParseStores(data)
+		if err != nil {
+			return c.fail(fmt.Errorf("invalid store list %s: %w", fs.Arg(1), err))
+		}
+
+		// Only the user's file is rewritten; the bundled list stays as is.
+		user, err := tesla.ReadStoresFile(tesla.StoresFile())
+		if err != nil {
+			return c.fail(err)
+		}
+		added, changed := user.Merge(update)
+		if err := user.Save(tesla.StoresFile()); err != nil {
+			return c.fail(err)
+		}
+		if err := tesla.ReloadStores(); err != nil {
+			return c.fail(err)
+		}
+		fmt.Fprintf(c.stdout, "Updated %s: %d stores added, %d changed\n", tesla.StoresFile(), added, changed)
+		return ExitOK
+
+	default:
+		fmt.Fprintln(c.stderr, "usage: tesla-cli stores [-country XX] [show <id> | update <file|url>]")
+		return ExitUsage
+	}
+}
+
+// readSource returns the contents of a local file or an http(s) URL.
+func (c *cli) readSource(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.ReadFile(source)
+	}
+
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", source, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// loadOrders returns the orders of account either from Tesla or, if cached
+// is set, from the saved orders file.
+func (c *cli) loadOrders(account tesla.Account, cached bool) (*tesla.OrderManager, []tesla.DetailedOrder, int) {
+	auth := account.NewTeslaAuth()
+	manager := account.NewOrderManager(auth)
+
+	if cached {
+		orders, err := manager.LoadOrdersFromFile()
+		if err != nil {
+			return nil, nil, c.fail(err)
+		}
+		return manager, orders, ExitOK
+	}
+
+	if err := auth.LoadTokens(); err != nil && !errors.Is(err, tesla.ErrNoTokens) {
+		return nil, nil, c.fail(fmt.Errorf("loading tokens of account %s: %w", account.Name, err))
+	} else if err != nil {
+		fmt.Fprintf(c.stderr, "account %s is not logged in, run 'tesla-cli login -account %s' first\n", account.Name, account.Name)
+		return nil, nil, ExitLoginRequired
+	}
+
+	orders, err := manager.GetDetailedOrders(c.ctx)
+	if err != nil && (orders == nil || errors.Is(err, tesla.ErrLoginRequired)) {
+		return nil, nil, c.fail(err)
+	}
+	if err != nil {
+		// Orders whose details failed carry their saved details.
+		fmt.Fprintf(c.stderr, "warning: showing saved details for orders that could not be refreshed:\n%v\n", err)
+	}
+
+	return manager, orders, ExitOK
+}
+
+func (c *cli) printJSON(v interface{}) int {
+	encoder := json.NewEncoder(c.stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return c.fail(err)
+	}
+	return ExitOK
+}
+
+// fail reports err and maps it to an exit code.
+func (c *cli) fail(err error) int {
+	if errors.Is(err, tesla.ErrLoginRequired) {
+		fmt.Fprintf(c.stderr, "%v, run 'tesla-cli login'\n", err)
+		return ExitLoginRequired
+	}
+
+	fmt.Fprintf(c.stderr, "error: %v\n", err)
+	return ExitError
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
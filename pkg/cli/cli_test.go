@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/mockserver"
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+// setup points the configuration directory and the endpoints of the CLI at
+// a temporary directory and a mock server running scenario.
+func setup(t *testing.T, scenario mockserver.Scenario) *httptest.Server {
+	t.Helper()
+
+	configDir := tesla.ConfigDir
+	tesla.ConfigDir = t.TempDir()
+	t.Cleanup(func() { tesla.ConfigDir = configDir })
+
+	server := httptest.NewServer(mockserver.New(scenario))
+	t.Cleanup(server.Close)
+
+	t.Setenv("TESLA_API_BASE_URL", server.URL)
+	t.Setenv(tesla.TokenStoreEnv, "file")
+	return server
+}
+
+// login saves tokens issued by the mock server for the active account, as
+// the interactive login would.
+func login(t *testing.T, server *httptest.Server) {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(server.URL + "/oauth2/v3/authorize?redirect_uri=http://localhost/callback&code_challenge=challenge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	location, err := resp.Location()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = http.PostForm(server.URL+"/oauth2/v3/token", url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {location.Query().Get("code")},
+		"code_verifier": {"verifier"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var tokens tesla.TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		t.Fatal(err)
+	}
+
+	store := &tesla.PlainFileStore{Path: tesla.Account{Name: tesla.DefaultAccountName}.TokenFile()}
+	if err := store.Save(tesla.Tokens{AccessToken: tokens.AccessToken, RefreshToken: tokens.RefreshToken}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func run(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := Run(context.Background(), args, strings.NewReader(""), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunUsage(t *testing.T) {
+	setup(t, mockserver.DefaultScenario())
+
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{nil, ExitUsage},
+		{[]string{"bogus"}, ExitUsage},
+		{[]string{"help"}, ExitOK},
+		{[]string{"status", "-bogus"}, ExitUsage},
+		{[]string{"status", "-account", "nobody"}, ExitUsage},
+		{[]string{"details"}, ExitUsage},
+		{[]string{"history", "RN1", "RN2"}, ExitUsage},
+		{[]string{"accounts", "add"}, ExitUsage},
+		{[]string{"stores", "-account", tesla.DefaultAccountName, "-country", "DE"}, ExitOK},
+		{[]string{"stores", "show", "x"}, ExitUsage},
+	}
+	for _, tt := range tests {
+		if code, _, stderr := run(tt.args...); code != tt.want {
+			t.Errorf("Run(%q) = %d, want %d (stderr %q)", tt.args, code, tt.want, stderr)
+		}
+	}
+}
+
+func TestRunNotLoggedIn(t *testing.T) {
+	setup(t, mockserver.DefaultScenario())
+
+	for _, args := range [][]string{{"status"}, {"diff"}, {"watch", "-once"}} {
+		if code, _, stderr := run(args...); code != ExitLoginRequired {
+			t.Errorf("Run(%q) = %d, want %d (stderr %q)", args, code, ExitLoginRequired, stderr)
+		}
+	}
+}
+
+func TestRunOrders(t *testing.T) {
+	server := setup(t, mockserver.DefaultScenario())
+	login(t, server)
+
+	// Every fetch advances the scenario until its last step, which repeats.
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"diff"}, ExitOK},
+		{[]string{"diff", "-json"}, ExitChanges},
+		{[]string{"diff", "-no-save"}, ExitChanges},
+		{[]string{"diff"}, ExitChanges},
+		{[]string{"diff"}, ExitOK},
+		{[]string{"details", "-cached", "RN100000001"}, ExitOK},
+		{[]string{"details", "-cached", "RN999"}, ExitError},
+		{[]string{"history", "RN100000001"}, ExitOK},
+	}
+	for _, tt := range tests {
+		if code, _, stderr := run(tt.args...); code != tt.want {
+			t.Errorf("Run(%q) = %d, want %d (stderr %q)", tt.args, code, tt.want, stderr)
+		}
+	}
+
+	code, stdout, stderr := run("status", "-json", "-cached")
+	if code != ExitOK {
+		t.Fatalf("status = %d (stderr %q)", code, stderr)
+	}
+	var orders []map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &orders); err != nil {
+		t.Fatalf("status -json output %q: %v", stdout, err)
+	}
+	if len(orders) != 2 {
+		t.Fatalf("status -json listed %d orders, want 2", len(orders))
+	}
+	for _, order := range orders {
+		if order["Status"] != "BOOKED" || order["Stale"] != false {
+			t.Errorf("status -json order = %v", order)
+		}
+	}
+}
+
+func TestRunStatusStale(t *testing.T) {
+	order := tesla.Order{ReferenceNumber: "RN1", OrderStatus: "BOOKED"}
+	server := setup(t, mockserver.Scenario{Steps: []mockserver.Step{
+		{Orders: []tesla.Order{order}, Tasks: map[string]map[string]interface{}{"RN1": {}}},
+		// The details of RN1 can no longer be fetched.
+		{Orders: []tesla.Order{order}},
+	}})
+	login(t, server)
+
+	if code, _, stderr := run("diff"); code != ExitOK {
+		t.Fatalf("diff = %d (stderr %q)", code, stderr)
+	}
+
+	code, stdout, stderr := run("status", "-json")
+	if code != ExitOK {
+		t.Fatalf("status = %d (stderr %q)", code, stderr)
+	}
+	var orders []map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &orders); err != nil {
+		t.Fatalf("status -json output %q: %v", stdout, err)
+	}
+	if len(orders) != 1 || orders[0]["Status"] != "BOOKED" || orders[0]["Stale"] != true {
+		t.Errorf("status -json = %v, want RN1 BOOKED and stale", orders)
+	}
+
+	if _, stdout, _ := run("status"); !strings.Contains(stdout, "BOOKED (stale)") {
+		t.Errorf("status table does not mark the stale order:\n%s", stdout)
+	}
+}
+
+func TestRunRefreshTokenRejected(t *testing.T) {
+	setup(t, mockserver.DefaultScenario())
+
+	store := &tesla.PlainFileStore{Path: tesla.Account{Name: tesla.DefaultAccountName}.TokenFile()}
+	if err := store.Save(tesla.Tokens{AccessToken: "expired", RefreshToken: "revoked"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if code, _, stderr := run("status"); code != ExitLoginRequired {
+		t.Errorf("status with a rejected refresh token = %d, want %d (stderr %q)", code, ExitLoginRequired, stderr)
+	}
+}
@@ -0,0 +1,251 @@
+package gui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/i18n"
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+// accountSession holds the authentication and order state of one account.
+type accountSession struct {
+	account tesla.Account
+	auth    *tesla.TeslaAuth
+	manager *tesla.OrderManager
+	profile tesla.Profile
+}
+
+func newAccountSession(account tesla.Account) *accountSession {
+	auth := account.NewTeslaAuth()
+	return &accountSession{
+		account: account,
+		auth:    auth,
+		manager: account.NewOrderManager(auth),
+		profile: account.Profile(),
+	}
+}
+
+// label names the account in the order list, with the email of its profile
+// when known, so accounts with similar names can be told apart.
+func (a *accountSession) label() string {
+	if a.profile.Email != "" {
+		return fmt.Sprintf("%s (%s)", a.account.Name, a.profile.Email)
+	}
+	return a.account.Name
+}
+
+// hasTokens loads the account's tokens if needed and reports whether it is
+// logged in.
+func (a *accountSession) hasTokens() bool {
+	if a.auth.AccessToken != "" || a.auth.RefreshToken != "" {
+		return true
+	}
+	return a.auth.LoadTokens() == nil
+}
+
+// accountOptions returns the entries of the account switcher, starting with
+// the aggregated view.
+func (s *OrdersScreen) accountOptions() []string {
+	options := []string{i18n.Text("all_accounts")}
+	for _, session := range s.sessions {
+		options = append(options, session.account.Name)
+	}
+	return options
+}
+
+func (s *OrdersScreen) createAccountControls() fyne.CanvasObject {
+	s.accountLabel = widget.NewLabel(i18n.Text("account") + ":")
+
+	s.accountSelect = widget.NewSelect(s.accountOptions(), func(selected string) {
+		if selected == i18n.Text("all_accounts") {
+			s.selectedAccount = ""
+		} else {
+			s.selectedAccount = selected
+			if err := s.accounts.SetActive(selected); err != nil {
+				fmt.Printf("Error saving active account: %v\n", err)
+			}
+		}
+		s.applyAccountFilter()
+	})
+	if len(s.sessions) > 1 {
+		s.accountSelect.SetSelected(i18n.Text("all_accounts"))
+	} else {
+		s.accountSelect.SetSelected(s.accounts.Active)
+	}
+
+	s.addAccountButton = widget.NewButton(i18n.Text("add_account"), s.showAddAccountDialog)
+	s.marketButton = widget.NewButton(i18n.Text("market"), s.showMarketDialog)
+
+	return container.NewHBox(s.accountLabel, s.accountSelect, s.addAccountButton, s.marketButton)
+}
+
+func (s *OrdersScreen) showAddAccountDialog() {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder(i18n.Text("account_name_hint"))
+
+	dialog.ShowForm(
+		i18n.Text("add_account"),
+		i18n.Text("add_account"),
+		i18n.Text("cancel"),
+		[]*widget.FormItem{widget.NewFormItem(i18n.Text("account_name"), nameEntry)},
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+
+			account, err := s.accounts.Add(nameEntry.Text)
+			if err != nil {
+				dialog.ShowError(err, s.window)
+				return
+			}
+			if err := s.accounts.SetActive(account.Name); err != nil {
+				fmt.Printf("Error saving active account: %v\n", err)
+			}
+
+			s.stopRefresh()
+			s.onAddAccount(account)
+		},
+		s.window,
+	)
+}
+
+// showMarketDialog edits the country and language of the current account.
+// Empty fields are detected from the orders.
+func (s *OrdersScreen) showMarketDialog() {
+	session := s.currentSession()
+
+	countryEntry := widget.NewEntry()
+	countryEntry.SetPlaceHolder(i18n.Text("market_auto_hint"))
+	countryEntry.SetText(session.account.Market.Country)
+
+	languageEntry := widget.NewEntry()
+	languageEntry.SetPlaceHolder(i18n.Text("market_auto_hint"))
+	languageEntry.SetText(session.account.Market.Language)
+
+	dialog.ShowForm(
+		fmt.Sprintf("%s: %s", i18n.Text("market"), session.account.Name),
+		i18n.Text("save"),
+		i18n.Text("cancel"),
+		[]*widget.FormItem{
+			widget.NewFormItem(i18n.Text("market_country"), countryEntry),
+			widget.NewFormItem(i18n.Text("market_language"), languageEntry),
+		},
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+
+			market, err := tesla.NewMarket(countryEntry.Text, languageEntry.Text)
+			if err != nil {
+				dialog.ShowError(err, s.window)
+				return
+			}
+
+			if err := s.accounts.SetMarket(session.account.Name, market); err != nil {
+				dialog.ShowError(err, s.window)
+				return
+			}
+			session.account.Market = market
+			session.manager.Market = market
+
+			s.fetchOrders()
+		},
+		s.window,
+	)
+}
+
+// currentSession returns the session the logout button acts on: the
+// selected account, or the last active one in the aggregated view.
+func (s *OrdersScreen) currentSession() *accountSession {
+	name := s.selectedAccount
+	if name == "" {
+		name = s.accounts.Active
+	}
+
+	for _, session := range s.sessions {
+		if session.account.Name == name {
+			return session
+		}
+	}
+	return s.sessions[0]
+}
+
+// sessionFor returns the session an order was fetched with.
+func (s *OrdersScreen) sessionFor(order tesla.DetailedOrder) *accountSession {
+	if session, ok := s.orderAccounts[order.Order.ReferenceNumber]; ok {
+		return session
+	}
+	return s.currentSession()
+}
+
+// applyAccountFilter shows the orders of the selected account, or of all
+// accounts in the aggregated view.
+func (s *OrdersScreen) applyAccountFilter() {
+	orders := make([]tesla.DetailedOrder, 0, len(s.allOrders))
+	for _, order := range s.allOrders {
+		if s.selectedAccount == "" || s.sessionFor(order).account.Name == s.selectedAccount {
+			orders = append(orders, order)
+		}
+	}
+	s.orders = orders
+	s.updateProfileLabel()
+
+	if s.ordersList == nil {
+		return
+	}
+
+	// Stay on the order being looked at, so a refresh updates its details
+	// instead of jumping to the first order.
+	selected := 0
+	for i, order := range s.orders {
+		if order.Order.ReferenceNumber == s.currentOrderDetail.Order.ReferenceNumber {
+			selected = i
+		}
+	}
+
+	s.ordersList.UnselectAll()
+	s.ordersList.Refresh()
+	if len(s.orders) > 0 {
+		s.ordersList.Select(selected)
+		s.showOrderDetails(s.orders[selected])
+	} else if s.detailsContainer != nil {
+		s.currentOrderDetail = tesla.DetailedOrder{}
+		s.detailsContainer.Objects = []fyne.CanvasObject{container.NewVBox(s.noOrdersLabel)}
+		s.detailsContainer.Refresh()
+	}
+}
+
+// cachedOrders returns the saved orders of an account whose order list could
+// not be fetched, marked stale, so they stay in the aggregated view under
+// their account instead of disappearing.
+func cachedOrders(session *accountSession, orders []tesla.DetailedOrder, orderAccounts map[string]*accountSession) []tesla.DetailedOrder {
+	cached := make([]tesla.DetailedOrder, 0, len(orders))
+	for _, order := range orders {
+		order.Stale = true
+		orderAccounts[order.Order.ReferenceNumber] = session
+		cached = append(cached, order)
+	}
+	return cached
+}
+
+// updateProfileLabel shows who is logged in to the selected account. The
+// aggregated view labels every order instead.
+func (s *OrdersScreen) updateProfileLabel() {
+	if s.profileLabel == nil {
+		return
+	}
+	label := ""
+	if s.selectedAccount != "" || len(s.sessions) == 1 {
+		label = s.currentSession().profile.Label()
+	}
+	s.profileLabel.SetText(label)
+}
+
+func accountLoginRequiredMessage(account tesla.Account) string {
+	return fmt.Sprintf(i18n.Text("account_login_required"), account.Name)
+}
@@ -0,0 +1,36 @@
+package gui
+
+import (
+	"testing"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+func TestAccountSessionLabel(t *testing.T) {
+	session := &accountSession{account: tesla.Account{Name: "work"}}
+	if got := session.label(); got != "work" {
+		t.Errorf("label() without profile = %q, want %q", got, "work")
+	}
+
+	session.profile = tesla.Profile{Email: "driver@example.com", Name: "Test Driver"}
+	if got := session.label(); got != "work (driver@example.com)" {
+		t.Errorf("label() = %q, want %q", got, "work (driver@example.com)")
+	}
+}
+
+func TestCachedOrders(t *testing.T) {
+	session := &accountSession{account: tesla.Account{Name: "work"}}
+	saved := []tesla.DetailedOrder{{Order: tesla.Order{ReferenceNumber: "RN1"}}}
+	orderAccounts := make(map[string]*accountSession)
+
+	got := cachedOrders(session, saved, orderAccounts)
+	if len(got) != 1 || !got[0].Stale {
+		t.Fatalf("cachedOrders() = %+v, want the saved order marked stale", got)
+	}
+	if saved[0].Stale {
+		t.Error("cachedOrders() modified the saved orders")
+	}
+	if orderAccounts["RN1"] != session {
+		t.Error("cached order is not attributed to its account")
+	}
+}
@@ -1,9 +1,11 @@
 package gui
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"net/url"
 	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -22,6 +24,7 @@ type AuthScreen struct {
 	authURL        string
 	teslaAuth      *tesla.TeslaAuth
 	onComplete     func()
+	onCancel       func()
 	
 	headerLabel    *widget.Label
 	authDescLabel  *widget.Label
@@ -29,6 +32,13 @@ type AuthScreen struct {
 	submitButton   *widget.Button 
 	urlLabel       *widget.Label
 	langSelect     *widget.Select
+	backButton     *widget.Button
+	entry          *widget.Entry
+	autoCapture    *widget.Check
+	
+	// stopCapture ends the running callback capture, if any.
+	stopCapture    context.CancelFunc
+	captureServer  *tesla.CallbackServer
 }
 
 func NewAuthScreen(app fyne.App, window fyne.Window, teslaAuth *tesla.TeslaAuth, onComplete func()) *AuthScreen {
@@ -40,6 +50,12 @@ func NewAuthScreen(app fyne.App, window fyne.Window, teslaAuth *tesla.TeslaAuth,
 	}
 }
 
+// SetOnCancel adds a back button to the screen, used when logging in an
+// additional account while other accounts are already logged in.
+func (s *AuthScreen) SetOnCancel(onCancel func()) {
+	s.onCancel = onCancel
+}
+
 func (s *AuthScreen) Show() {
 	s.headerLabel = widget.NewLabelWithStyle(i18n.Text("auth_title"), fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
 	header := container.NewHBox(s.headerLabel)
@@ -58,7 +74,7 @@ func (s *AuthScreen) Show() {
 		),
 	)
 	
-	if err := s.teslaAuth.LoadTokensFromFile(); err == nil && s.teslaAuth.IsTokenValid() {
+	if err := s.teslaAuth.LoadTokens(); err == nil && s.teslaAuth.IsTokenValid() {
 		s.onComplete()
 		return
 	} else {
@@ -138,6 +154,15 @@ func (s *AuthScreen) updateLanguageUI() {
 			s.submitButton.Refresh()
 		}
 
+		if s.backButton != nil {
+			s.backButton.SetText(i18n.Text("back"))
+			s.backButton.Refresh()
+		}
+
+		if s.autoCapture != nil {
+			s.autoCapture.SetText(i18n.Text("auto_capture"))
+		}
+
 		if s.urlLabel != nil {
 			// Assuming s.urlLabel is for the redirect URL info which might change based on language
 			s.urlLabel.SetText(i18n.Text("redirect_url_info")) 
@@ -147,8 +172,6 @@ func (s *AuthScreen) updateLanguageUI() {
 }
 
 func (s *AuthScreen) showAuthForm() {
-	s.authURL = s.teslaAuth.GetAuthURL()
-	
 	s.headerLabel = widget.NewLabelWithStyle(i18n.Text("auth_title"), fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
 	header := container.NewHBox(s.headerLabel)
 	
@@ -159,68 +182,39 @@ func (s *AuthScreen) showAuthForm() {
 	s.authDescLabel = widget.NewLabelWithStyle(i18n.Text("auth_description"), fyne.TextAlignCenter, fyne.TextStyle{})
 	content := container.NewVBox(s.authDescLabel)
 	
-	s.openButton = widget.NewButton(i18n.Text("login"), func() {
-		if err := utils.OpenBrowser(s.authURL); err != nil {
-			dialog.ShowError(fmt.Errorf(i18n.Text("error_opening_browser"), err), s.window)
-		}
-	})
+	s.openButton = widget.NewButton(i18n.Text("login"), s.openLogin)
 	s.openButton.Importance = widget.HighImportance
 	
 	entry := widget.NewEntry()
 	entry.SetPlaceHolder(i18n.Text("auth_description"))
+	s.entry = entry
+	
+	s.autoCapture = widget.NewCheck(i18n.Text("auto_capture"), func(checked bool) {
+		if !checked {
+			s.cancelCapture()
+		}
+	})
+	s.autoCapture.SetChecked(true)
 	
 	s.submitButton = widget.NewButton(i18n.Text("login"), func() {
 		if entry.Text == "" {
-			dialog.ShowError(fmt.Errorf(i18n.Text("login_error")), s.window)
+			dialog.ShowError(errors.New(i18n.Text("login_error")), s.window)
 			return
 		}
 		
-		code, err := extractAuthCode(entry.Text)
+		code, err := s.teslaAuth.ParseCallback(entry.Text)
 		if err != nil {
-			alternativeCode := extractAuthCodeAlternative(entry.Text)
-			if alternativeCode != "" {
-				code = alternativeCode
-			} else {
-				dialog.ShowError(err, s.window)
-				return
-			}
+			dialog.ShowError(errors.New(callbackErrorText(err)), s.window)
+			return
 		}
 		
-		progress := dialog.NewProgressInfinite(i18n.Text("login_progress"), i18n.Text("loading"), s.window)
-		progress.Show()
-		
-		go func() {
-			defer progress.Hide()
-			if err := s.teslaAuth.ExchangeCodeForTokens(code); err != nil {
-				fyne.CurrentApp().SendNotification(&fyne.Notification{
-					Title:   i18n.Text("error"),
-					Content: fmt.Sprintf("%s %v", i18n.Text("login_error"), err),
-				})
-				dialog.ShowError(fmt.Errorf("%s %v", i18n.Text("login_error"), err), s.window)
-				return
-			}
-			
-			if err := s.teslaAuth.SaveTokensToFile(); err != nil {
-				fyne.CurrentApp().SendNotification(&fyne.Notification{
-					Title:   i18n.Text("error"),
-					Content: fmt.Sprintf("%s %v", i18n.Text("login_error"), err),
-				})
-				dialog.ShowError(fmt.Errorf("%s %v", i18n.Text("login_error"), err), s.window)
-				return
-			}
-			
-			fyne.CurrentApp().SendNotification(&fyne.Notification{
-				Title:   i18n.Text("login_success"),
-				Content: i18n.Text("login_success"),
-			})
-			
-			dialog.ShowInformation(i18n.Text("login_success"), i18n.Text("login_success"), s.window)
-			s.onComplete()
-		}()
+		s.cancelCapture()
+		s.completeLogin(code)
 	})
 	s.submitButton.Importance = widget.HighImportance
 	
 	buttonArea := container.NewHBox(layout.NewSpacer(), s.openButton, layout.NewSpacer())
+	captureArea := container.NewHBox(layout.NewSpacer(), s.autoCapture, layout.NewSpacer())
 	
 	s.urlLabel = widget.NewLabelWithStyle(i18n.Text("redirect_url_info"), fyne.TextAlignCenter, fyne.TextStyle{})
 	
@@ -228,6 +222,7 @@ func (s *AuthScreen) showAuthForm() {
 	
 	content.Add(container.NewPadded(widget.NewLabel("")))
 	content.Add(buttonArea)
+	content.Add(captureArea)
 	content.Add(container.NewPadded(widget.NewLabel("")))
 	content.Add(s.urlLabel)
 	content.Add(container.NewPadded(entry))
@@ -247,42 +242,167 @@ func (s *AuthScreen) showAuthForm() {
 	})
 }
 
-func extractAuthCode(url string) (string, error) {
-	if !strings.Contains(url, "code=") {
-		return "", fmt.Errorf(i18n.Text("invalid_url"))
-	}
+// openLogin starts a new login attempt and opens it in the browser. Every
+// attempt has its own verifier and state, so a redirect URL left over from
+// an earlier attempt is refused.
+func (s *AuthScreen) openLogin() {
+	s.cancelCapture()
 	
-	parts := strings.Split(url, "code=")
-	if len(parts) < 2 {
-		return "", fmt.Errorf(i18n.Text("invalid_url_format"))
+	authURL, err := s.teslaAuth.GetAuthURL()
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("%s %v", i18n.Text("login_error"), err), s.window)
+		return
 	}
+	s.authURL = authURL
 	
-	code := parts[1]
-	if idx := strings.Index(code, "&"); idx != -1 {
-		code = code[:idx]
+	if s.autoCapture.Checked {
+		s.startCapture()
+	}
+	if err := utils.OpenBrowser(s.authURL); err != nil {
+		dialog.ShowError(fmt.Errorf(i18n.Text("error_opening_browser"), err), s.window)
 	}
-	
-	return code, nil
 }
 
-func extractAuthCodeAlternative(urlStr string) string {
-	if !strings.HasPrefix(urlStr, "http") {
-		urlStr = "https://" + urlStr
+// callbackErrorText explains why a redirect URL cannot complete the login.
+func callbackErrorText(err error) string {
+	var callbackErr *tesla.CallbackError
+	switch {
+	case errors.Is(err, tesla.ErrStateMismatch):
+		return i18n.Text("login_state_mismatch")
+	case errors.Is(err, tesla.ErrNoPendingLogin):
+		return i18n.Text("login_not_started")
+	case errors.Is(err, tesla.ErrNoAuthCode):
+		return i18n.Text("invalid_url")
+	case errors.As(err, &callbackErr):
+		return fmt.Sprintf(i18n.Text("login_denied"), callbackErr)
+	default:
+		return fmt.Sprintf("%s: %v", i18n.Text("invalid_url_format"), err)
 	}
-	
-	parsedURL, err := url.Parse(urlStr)
-	if err != nil {
-		return ""
+}
+
+// completeLogin exchanges the authorization code for tokens and saves them.
+func (s *AuthScreen) completeLogin(code string) {
+	progress := dialog.NewProgressInfinite(i18n.Text("login_progress"), i18n.Text("loading"), s.window)
+	progress.Show()
+	
+	go func() {
+		defer progress.Hide()
+		if err := s.teslaAuth.ExchangeCodeForTokens(context.Background(), code); err != nil {
+			if errors.Is(err, tesla.ErrNoPendingLogin) {
+				err = errors.New(i18n.Text("login_not_started"))
+			}
+			fyne.CurrentApp().SendNotification(&fyne.Notification{
+				Title:   i18n.Text("error"),
+				Content: fmt.Sprintf("%s %v", i18n.Text("login_error"), err),
+			})
+			dialog.ShowError(fmt.Errorf("%s %v", i18n.Text("login_error"), err), s.window)
+			return
+		}
+		
+		if err := s.teslaAuth.SaveTokens(); err != nil {
+			fyne.CurrentApp().SendNotification(&fyne.Notification{
+				Title:   i18n.Text("error"),
+				Content: fmt.Sprintf("%s %v", i18n.Text("login_error"), err),
+			})
+			dialog.ShowError(fmt.Errorf("%s %v", i18n.Text("login_error"), err), s.window)
+			return
+		}
+		
+		fyne.CurrentApp().SendNotification(&fyne.Notification{
+			Title:   i18n.Text("login_success"),
+			Content: i18n.Text("login_success"),
+		})
+		
+		dialog.ShowInformation(i18n.Text("login_success"), i18n.Text("login_success"), s.window)
+		s.onComplete()
+	}()
+}
+
+// startCapture waits in the background for the browser to come back from
+// the login, so the redirect URL does not have to be pasted. A loopback
+// redirect URI is answered by a local listener; otherwise the clipboard is
+// watched for the callback URL of this login.
+func (s *AuthScreen) startCapture() {
+	if s.stopCapture != nil {
+		return
 	}
 	
-	queryParams, err := url.ParseQuery(parsedURL.RawQuery)
-	if err != nil {
-		return ""
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopCapture = cancel
+	
+	if tesla.IsLoopbackRedirect(s.teslaAuth.Endpoints.RedirectURI) {
+		server, err := s.teslaAuth.ListenForCallback()
+		if err == nil {
+			s.captureServer = server
+			go func() {
+				code, err := server.Wait(ctx)
+				if ctx.Err() != nil {
+					return
+				}
+				fyne.Do(func() {
+					if err != nil {
+						s.cancelCapture()
+						dialog.ShowError(errors.New(callbackErrorText(err)), s.window)
+						return
+					}
+					s.captured(code)
+				})
+			}()
+			return
+		}
+		fmt.Printf("Could not listen for the login callback, watching the clipboard instead: %v\n", err)
 	}
 	
-	code := queryParams.Get("code")
+	go s.watchClipboard(ctx)
+}
+
+func (s *AuthScreen) watchClipboard(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
 	
-	return code
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		
+		// The pending login is only touched on the main thread.
+		found := false
+		fyne.DoAndWait(func() {
+			content := s.app.Clipboard().Content()
+			if code, ok := s.teslaAuth.MatchCallback(content); ok {
+				found = true
+				s.entry.SetText(strings.TrimSpace(content))
+				s.captured(code)
+			}
+		})
+		if found {
+			return
+		}
+	}
+}
+
+// captured logs in with a code found by the capture, unless the capture was
+// stopped in the meantime.
+func (s *AuthScreen) captured(code string) {
+	if s.stopCapture == nil {
+		return
+	}
+	s.cancelCapture()
+	s.completeLogin(code)
+}
+
+func (s *AuthScreen) cancelCapture() {
+	if s.stopCapture != nil {
+		s.stopCapture()
+		s.stopCapture = nil
+	}
+	// Closed right away so the port is free for the next attempt.
+	if s.captureServer != nil {
+		s.captureServer.Close()
+		s.captureServer = nil
+	}
 }
 
 func (s *AuthScreen) GetContent() fyne.CanvasObject {
@@ -292,74 +412,51 @@ func (s *AuthScreen) GetContent() fyne.CanvasObject {
 	s.langSelect = s.createLanguageSelector()
 	header.Add(layout.NewSpacer())
 	header.Add(s.langSelect)
-
-	s.authURL = s.teslaAuth.GetAuthURL()
 	
+	if s.onCancel != nil {
+		s.backButton = widget.NewButton(i18n.Text("back"), func() {
+			s.cancelCapture()
+			s.onCancel()
+		})
+		header.Add(s.backButton)
+	}
+
 	s.authDescLabel = widget.NewLabelWithStyle(i18n.Text("auth_description"), fyne.TextAlignCenter, fyne.TextStyle{})
 	contentVBox := container.NewVBox(s.authDescLabel)
 	
-	s.openButton = widget.NewButton(i18n.Text("login"), func() {
-		if err := utils.OpenBrowser(s.authURL); err != nil {
-			dialog.ShowError(fmt.Errorf(i18n.Text("error_opening_browser"), err), s.window)
-		}
-	})
+	s.openButton = widget.NewButton(i18n.Text("login"), s.openLogin)
 	s.openButton.Importance = widget.HighImportance
 	
 	entry := widget.NewEntry()
 	entry.SetPlaceHolder(i18n.Text("auth_description"))
+	s.entry = entry
+	
+	s.autoCapture = widget.NewCheck(i18n.Text("auto_capture"), func(checked bool) {
+		if !checked {
+			s.cancelCapture()
+		}
+	})
+	s.autoCapture.SetChecked(true)
 	
 	s.submitButton = widget.NewButton(i18n.Text("login"), func() {
 		if entry.Text == "" {
-			dialog.ShowError(fmt.Errorf(i18n.Text("login_error")), s.window)
+			dialog.ShowError(errors.New(i18n.Text("login_error")), s.window)
 			return
 		}
 		
-		code, err := extractAuthCode(entry.Text)
+		code, err := s.teslaAuth.ParseCallback(entry.Text)
 		if err != nil {
-			alternativeCode := extractAuthCodeAlternative(entry.Text)
-			if alternativeCode != "" {
-				code = alternativeCode
-			} else {
-				dialog.ShowError(err, s.window)
-				return
-			}
+			dialog.ShowError(errors.New(callbackErrorText(err)), s.window)
+			return
 		}
 		
-		progress := dialog.NewProgressInfinite(i18n.Text("login_progress"), i18n.Text("loading"), s.window)
-		progress.Show()
-		
-		go func() {
-			defer progress.Hide()
-			if err := s.teslaAuth.ExchangeCodeForTokens(code); err != nil {
-				fyne.CurrentApp().SendNotification(&fyne.Notification{
-					Title:   i18n.Text("error"),
-					Content: fmt.Sprintf("%s %v", i18n.Text("login_error"), err),
-				})
-				dialog.ShowError(fmt.Errorf("%s %v", i18n.Text("login_error"), err), s.window)
-				return
-			}
-			
-			if err := s.teslaAuth.SaveTokensToFile(); err != nil {
-				fyne.CurrentApp().SendNotification(&fyne.Notification{
-					Title:   i18n.Text("error"),
-					Content: fmt.Sprintf("%s %v", i18n.Text("login_error"), err),
-				})
-				dialog.ShowError(fmt.Errorf("%s %v", i18n.Text("login_error"), err), s.window)
-				return
-			}
-			
-			fyne.CurrentApp().SendNotification(&fyne.Notification{
-				Title:   i18n.Text("login_success"),
-				Content: i18n.Text("login_success"),
-			})
-			
-			dialog.ShowInformation(i18n.Text("login_success"), i18n.Text("login_success"), s.window)
-			s.onComplete()
-		}()
+		s.cancelCapture()
+		s.completeLogin(code)
 	})
 	s.submitButton.Importance = widget.HighImportance
 	
 	buttonArea := container.NewHBox(layout.NewSpacer(), s.openButton, layout.NewSpacer())
+	captureArea := container.NewHBox(layout.NewSpacer(), s.autoCapture, layout.NewSpacer())
 	
 	s.urlLabel = widget.NewLabelWithStyle(i18n.Text("redirect_url_info"), fyne.TextAlignCenter, fyne.TextStyle{})
 	
@@ -367,6 +464,7 @@ func (s *AuthScreen) GetContent() fyne.CanvasObject {
 	
 	contentVBox.Add(container.NewPadded(widget.NewLabel("")))
 	contentVBox.Add(buttonArea)
+	contentVBox.Add(captureArea)
 	contentVBox.Add(container.NewPadded(widget.NewLabel("")))
 	contentVBox.Add(s.urlLabel)
 	contentVBox.Add(container.NewPadded(entry))
@@ -0,0 +1,33 @@
+package gui
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/i18n"
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+func TestCallbackErrorText(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"earlier attempt", tesla.ErrStateMismatch, i18n.Text("login_state_mismatch")},
+		{"not started", tesla.ErrNoPendingLogin, i18n.Text("login_not_started")},
+		{"no code", tesla.ErrNoAuthCode, i18n.Text("invalid_url")},
+		{"wrapped", fmt.Errorf("callback: %w", tesla.ErrStateMismatch), i18n.Text("login_state_mismatch")},
+		{"denied", &tesla.CallbackError{Code: "access_denied"}, fmt.Sprintf(i18n.Text("login_denied"), "login failed: access_denied")},
+		{"other", errors.New("bad"), i18n.Text("invalid_url_format") + ": bad"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := callbackErrorText(tt.err); got != tt.want {
+				t.Errorf("callbackErrorText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/i18n"
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+// taskProgressLabels maps the checklist progress to its i18n key.
+var taskProgressLabels = map[tesla.TaskProgress]string{
+	tesla.TaskComplete: "task_complete",
+	tesla.TaskPending:  "task_pending",
+	tesla.TaskBlocked:  "task_blocked",
+}
+
+// createChecklistContainer lists every task of the order with its progress
+// and the card text Tesla shows for it.
+func (s *OrdersScreen) createChecklistContainer(order tesla.DetailedOrder) fyne.CanvasObject {
+	items := order.Details.Checklist()
+	complete, total := tesla.ChecklistCounts(items)
+
+	title := newSectionTitle(fmt.Sprintf("%s (%d/%d)", i18n.Text("task_checklist"), complete, total))
+	s.orderTitles = append(s.orderTitles, title)
+	checklistContainer := container.NewVBox(title, widget.NewSeparator())
+
+	if len(items) == 0 {
+		checklistContainer.Add(container.NewPadded(
+			widget.NewLabelWithStyle(i18n.Text("no_tasks"), fyne.TextAlignLeading, fyne.TextStyle{Italic: true}),
+		))
+		return checklistContainer
+	}
+
+	for _, item := range items {
+		name := widget.NewLabelWithStyle(taskName(item.Key), fyne.TextAlignLeading, fyne.TextStyle{Bold: item.Progress == tesla.TaskPending})
+		progress := widget.NewLabel(i18n.Text(taskProgressLabels[item.Progress]))
+		rows := []fyne.CanvasObject{container.NewBorder(nil, nil, name, progress)}
+
+		if card := item.Card.Text(); card != "" {
+			cardLabel := widget.NewLabel(card)
+			cardLabel.Wrapping = fyne.TextWrapWord
+			rows = append(rows, cardLabel)
+		}
+
+		icon := widget.NewIcon(taskProgressIcon(item.Progress))
+		checklistContainer.Add(container.NewBorder(nil, nil, container.NewVBox(icon), nil, container.NewVBox(rows...)))
+	}
+
+	return checklistContainer
+}
+
+func taskProgressIcon(progress tesla.TaskProgress) fyne.Resource {
+	switch progress {
+	case tesla.TaskComplete:
+		return theme.CheckButtonCheckedIcon()
+	case tesla.TaskBlocked:
+		return theme.ContentRemoveIcon()
+	default:
+		return theme.CheckButtonIcon()
+	}
+}
+
+// taskName returns the translated name of a task, or a readable form of
+// the key for tasks the app does not know, "deliveryAcceptance" becoming
+// "Delivery Acceptance".
+func taskName(key string) string {
+	if text := i18n.Text("task_" + key); text != "task_"+key {
+		return text
+	}
+
+	var b strings.Builder
+	for i, r := range key {
+		switch {
+		case i == 0:
+			r = unicode.ToUpper(r)
+		case unicode.IsUpper(r):
+			b.WriteRune(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
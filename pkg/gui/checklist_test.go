@@ -0,0 +1,133 @@
+package gui
+
+import (
+	"fmt"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/i18n"
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+func TestTaskName(t *testing.T) {
+	if got := taskName("finalPayment"); got != i18n.Text("task_finalPayment") {
+		t.Errorf("taskName(finalPayment) = %q", got)
+	}
+	if got := taskName("wallChargerInstall"); got != "Wall Charger Install" {
+		t.Errorf("taskName(wallChargerInstall) = %q, want %q", got, "Wall Charger Install")
+	}
+}
+
+// checklistRow is what a row of the checklist shows.
+type checklistRow struct {
+	icon     fyne.Resource
+	name     string
+	bold     bool
+	progress string
+	card     string
+}
+
+func TestCreateChecklistContainer(t *testing.T) {
+	test.NewTempApp(t)
+
+	order := tesla.DetailedOrder{Details: tesla.OrderDetails{Tasks: map[string]interface{}{
+		"registration": map[string]interface{}{"complete": true, "enabled": true},
+		"scheduling": map[string]interface{}{
+			"complete": false,
+			"enabled":  true,
+			"card":     map[string]interface{}{"title": "Schedule Delivery"},
+		},
+		"finalPayment": map[string]interface{}{"complete": false, "enabled": false},
+	}}}
+
+	s := &OrdersScreen{}
+	box := s.createChecklistContainer(order).(*fyne.Container)
+
+	title := box.Objects[0]
+	if len(s.orderTitles) != 1 || s.orderTitles[0] != title {
+		t.Error("the checklist title is not registered for theme updates")
+	}
+	if want := fmt.Sprintf("%s (1/3)", i18n.Text("task_checklist")); s.orderTitles[0].Text != want {
+		t.Errorf("title = %q, want %q", s.orderTitles[0].Text, want)
+	}
+
+	var rows []checklistRow
+	for _, object := range box.Objects[2:] {
+		rows = append(rows, readChecklistRow(t, object))
+	}
+
+	want := []checklistRow{
+		{theme.CheckButtonCheckedIcon(), i18n.Text("task_registration"), false, i18n.Text("task_complete"), ""},
+		{theme.CheckButtonIcon(), i18n.Text("task_scheduling"), true, i18n.Text("task_pending"), "Schedule Delivery"},
+		{theme.ContentRemoveIcon(), i18n.Text("task_finalPayment"), false, i18n.Text("task_blocked"), ""},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("%d rows, want %d", len(rows), len(want))
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, rows[i], want[i])
+		}
+	}
+}
+
+func TestCreateChecklistContainerEmpty(t *testing.T) {
+	test.NewTempApp(t)
+
+	s := &OrdersScreen{}
+	box := s.createChecklistContainer(tesla.DetailedOrder{}).(*fyne.Container)
+	label := box.Objects[2].(*fyne.Container).Objects[0].(*widget.Label)
+	if label.Text != i18n.Text("no_tasks") {
+		t.Errorf("empty checklist shows %q", label.Text)
+	}
+}
+
+// readChecklistRow takes a row apart: the icon on the left, then the name
+// and progress above the optional card text.
+func readChecklistRow(t *testing.T, object fyne.CanvasObject) checklistRow {
+	t.Helper()
+
+	var row checklistRow
+	var content, left *fyne.Container
+	for _, child := range object.(*fyne.Container).Objects {
+		if c, ok := child.(*fyne.Container); ok && len(c.Objects) == 1 {
+			if _, ok := c.Objects[0].(*widget.Icon); ok {
+				left = c
+				continue
+			}
+		}
+		content = child.(*fyne.Container)
+	}
+	if left == nil || content == nil {
+		t.Fatalf("unexpected row layout %#v", object)
+	}
+	row.icon = left.Objects[0].(*widget.Icon).Resource
+
+	header := content.Objects[0].(*fyne.Container)
+	labels := labelsOf(header)
+	if len(labels) != 2 {
+		t.Fatalf("row header has %d labels", len(labels))
+	}
+	row.name, row.bold, row.progress = labels[0].Text, labels[0].TextStyle.Bold, labels[1].Text
+
+	if len(content.Objects) > 1 {
+		row.card = content.Objects[1].(*widget.Label).Text
+	}
+	return row
+}
+
+// labelsOf returns the labels of a border container in layout order: the
+// left one before the right one.
+func labelsOf(c *fyne.Container) []*widget.Label {
+	var labels []*widget.Label
+	for _, object := range c.Objects {
+		if label, ok := object.(*widget.Label); ok {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
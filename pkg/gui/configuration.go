@@ -0,0 +1,63 @@
+package gui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/i18n"
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+// optionCategoryLabels maps the option categories to their i18n keys.
+var optionCategoryLabels = map[tesla.OptionCategory]string{
+	tesla.OptionTrim:      "trim",
+	tesla.OptionPaint:     "color",
+	tesla.OptionInterior:  "interior",
+	tesla.OptionWheels:    "wheels",
+	tesla.OptionAutopilot: "autopilot",
+	tesla.OptionOther:     "other_options",
+}
+
+// createConfigurationContainer shows the decoded model and option codes,
+// so the order can be checked against what was configured.
+func (s *OrdersScreen) createConfigurationContainer(order tesla.DetailedOrder) fyne.CanvasObject {
+	title := newSectionTitle(i18n.Text("vehicle_details"))
+	s.orderTitles = append(s.orderTitles, title)
+	configContainer := container.NewVBox(title, widget.NewSeparator())
+
+	config := tesla.Options().Decode(order.Order)
+
+	form := widget.NewForm()
+	form.Append(i18n.Text("model"), widget.NewLabel(config.Model))
+	for _, category := range tesla.OptionCategories {
+		if options := config.ByCategory(category); len(options) > 0 {
+			form.Append(i18n.Text(optionCategoryLabels[category]), widget.NewLabel(optionLabels(options)))
+		}
+	}
+	configContainer.Add(container.NewPadded(form))
+
+	if len(config.Options) == 0 {
+		message := widget.NewLabelWithStyle(i18n.Text("no_option_codes"), fyne.TextAlignLeading, fyne.TextStyle{Italic: true})
+		message.Wrapping = fyne.TextWrapWord
+		configContainer.Add(container.NewPadded(message))
+		return configContainer
+	}
+
+	codes := widget.NewForm()
+	codes.Append(i18n.Text("option_codes"), s.createHighlightedLabel(order.Order.MktOptions, "Options_"+order.Order.ReferenceNumber))
+	configContainer.Add(container.NewPadded(codes))
+
+	return configContainer
+}
+
+// optionLabels lists options one per line.
+func optionLabels(options []tesla.Option) string {
+	labels := make([]string, len(options))
+	for i, option := range options {
+		labels[i] = option.Label()
+	}
+	return strings.Join(labels, "\n")
+}
@@ -0,0 +1,303 @@
+package gui
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"net/url"
+	"runtime"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/i18n"
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+// deliveryMapSize is the size of the schematic map. There are no map tiles
+// offline, so it only places the delivery center and home relative to each
+// other.
+var deliveryMapSize = fyne.NewSize(360, 200)
+
+const deliveryMapMargin = 28
+
+// createDeliveryMap draws the delivery center and, if set, the home
+// location with the straight line between them.
+func (s *OrdersScreen) createDeliveryMap(store tesla.Store, distance string) fyne.CanvasObject {
+	background := canvas.NewRectangle(theme.InputBackgroundColor())
+	background.StrokeColor = theme.ShadowColor()
+	background.StrokeWidth = 1
+	background.SetMinSize(deliveryMapSize)
+	background.Resize(deliveryMapSize)
+
+	objects := []fyne.CanvasObject{background}
+	for i := 1; i < 4; i++ {
+		x := deliveryMapSize.Width * float32(i) / 4
+		y := deliveryMapSize.Height * float32(i) / 4
+		objects = append(objects,
+			mapLine(fyne.NewPos(x, 0), fyne.NewPos(x, deliveryMapSize.Height), theme.ShadowColor(), 1),
+			mapLine(fyne.NewPos(0, y), fyne.NewPos(deliveryMapSize.Width, y), theme.ShadowColor(), 1),
+		)
+	}
+
+	points := []tesla.Location{store.Location()}
+	if !s.home.IsZero() {
+		points = append(points, s.home)
+	}
+	positions := projectPoints(points, deliveryMapSize, deliveryMapMargin)
+
+	if len(positions) == 2 {
+		objects = append(objects, mapLine(positions[0], positions[1], theme.ForegroundColor(), 2))
+		middle := fyne.NewPos((positions[0].X+positions[1].X)/2, (positions[0].Y+positions[1].Y)/2)
+		objects = append(objects, mapLabel(distance, middle, theme.ForegroundColor(), true))
+
+		objects = append(objects, mapMarker(positions[1], theme.PrimaryColor()), mapLabel(i18n.Text("home"), positions[1], theme.PrimaryColor(), false))
+	}
+
+	storeLabel := store.Name
+	if store.Approximate {
+		storeLabel = fmt.Sprintf("%s (%s)", storeLabel, i18n.Text("approximate_location"))
+	}
+	objects = append(objects, mapMarker(positions[0], theme.ErrorColor()), mapLabel(storeLabel, positions[0], theme.ErrorColor(), false))
+
+	return container.NewHBox(container.NewWithoutLayout(objects...))
+}
+
+func mapLine(from, to fyne.Position, stroke color.Color, width float32) fyne.CanvasObject {
+	line := canvas.NewLine(stroke)
+	line.StrokeWidth = width
+	line.Position1 = from
+	line.Position2 = to
+	return line
+}
+
+func mapMarker(at fyne.Position, fill color.Color) fyne.CanvasObject {
+	const radius = 6
+	marker := canvas.NewCircle(fill)
+	marker.StrokeColor = theme.BackgroundColor()
+	marker.StrokeWidth = 2
+	marker.Move(fyne.NewPos(at.X-radius, at.Y-radius))
+	marker.Resize(fyne.NewSize(2*radius, 2*radius))
+	return marker
+}
+
+// mapLabel places text next to a marker, or centred on the point if
+// centred is set, keeping it inside the map.
+func mapLabel(text string, at fyne.Position, fill color.Color, centred bool) fyne.CanvasObject {
+	label := canvas.NewText(text, fill)
+	label.TextSize = theme.CaptionTextSize()
+	label.TextStyle = fyne.TextStyle{Bold: centred}
+	size := label.MinSize()
+
+	pos := fyne.NewPos(at.X+8, at.Y-size.Height/2)
+	if centred {
+		pos = fyne.NewPos(at.X-size.Width/2, at.Y-size.Height-2)
+	}
+	pos.X = float32(math.Max(2, math.Min(float64(pos.X), float64(deliveryMapSize.Width-size.Width-2))))
+	pos.Y = float32(math.Max(2, math.Min(float64(pos.Y), float64(deliveryMapSize.Height-size.Height-2))))
+
+	label.Move(pos)
+	label.Resize(size)
+	return label
+}
+
+// projectPoints maps the locations into a box of the given size, keeping
+// margin free on every side. It uses an equirectangular projection, which
+// is accurate enough over the distance to a delivery center. A single point,
+// or points at the same place, end up in the middle.
+func projectPoints(points []tesla.Location, size fyne.Size, margin float32) []fyne.Position {
+	if len(points) == 0 {
+		return nil
+	}
+
+	var meanLatitude float64
+	for _, p := range points {
+		meanLatitude += p.Latitude
+	}
+	meanLatitude /= float64(len(points))
+	lonScale := math.Cos(meanLatitude * math.Pi / 180)
+
+	xs := make([]float64, len(points))
+	ys := make([]float64, len(points))
+	minX, maxX, minY, maxY := math.Inf(1), math.Inf(-1), math.Inf(1), math.Inf(-1)
+	for i, p := range points {
+		xs[i] = p.Longitude * lonScale
+		ys[i] = -p.Latitude
+		minX, maxX = math.Min(minX, xs[i]), math.Max(maxX, xs[i])
+		minY, maxY = math.Min(minY, ys[i]), math.Max(maxY, ys[i])
+	}
+
+	width := float64(size.Width - 2*margin)
+	height := float64(size.Height - 2*margin)
+	scale := math.Inf(1)
+	if maxX > minX {
+		scale = width / (maxX - minX)
+	}
+	if maxY > minY {
+		scale = math.Min(scale, height/(maxY-minY))
+	}
+	if math.IsInf(scale, 1) {
+		scale = 0
+	}
+
+	// Centre the drawing in whichever direction it does not fill.
+	offsetX := float64(size.Width)/2 - (minX+maxX)/2*scale
+	offsetY := float64(size.Height)/2 - (minY+maxY)/2*scale
+
+	positions := make([]fyne.Position, len(points))
+	for i := range points {
+		positions[i] = fyne.NewPos(float32(xs[i]*scale+offsetX), float32(ys[i]*scale+offsetY))
+	}
+	return positions
+}
+
+// formatDistance formats a distance in kilometres, or in miles if the
+// market uses them.
+func formatDistance(km float64, miles bool) string {
+	value, unit := km, "km"
+	if miles {
+		value, unit = km/1.609344, "mi"
+	}
+	if value < 10 {
+		return fmt.Sprintf("%.1f %s", value, unit)
+	}
+	return fmt.Sprintf("%.0f %s", value, unit)
+}
+
+// mapURL returns the URL that opens the store in the map application of
+// goos: Apple Maps on macOS, Windows Maps on Windows and OpenStreetMap in
+// the browser elsewhere. Exact coordinates are used as they are; a store
+// with town-level ones is searched for by name and city instead, as a pin
+// in the town centre would not find it.
+func mapURL(store tesla.Store, goos string) string {
+	useCoordinates := store.HasLocation() && !store.Approximate
+
+	if !useCoordinates {
+		var parts []string
+		for _, part := range []string{store.Name, store.Address, store.City, store.Country} {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+		query := strings.Join(parts, ", ")
+
+		switch goos {
+		case "darwin":
+			return "https://maps.apple.com/?q=" + url.QueryEscape(query)
+		case "windows":
+			return "bingmaps:?q=" + url.QueryEscape(query)
+		default:
+			return "https://www.openstreetmap.org/search?query=" + url.QueryEscape(query)
+		}
+	}
+
+	lat, lon := store.Latitude, store.Longitude
+	switch goos {
+	case "darwin":
+		return fmt.Sprintf("https://maps.apple.com/?ll=%f,%f&q=%s", lat, lon, url.QueryEscape(store.Name))
+	case "windows":
+		return fmt.Sprintf("bingmaps:?collection=point.%f_%f_%s&lvl=14", lat, lon, url.PathEscape(store.Name))
+	default:
+		return fmt.Sprintf("https://www.openstreetmap.org/?mlat=%f&mlon=%f#map=14/%f/%f", lat, lon, lat, lon)
+	}
+}
+
+// createStoreLocation builds the map part of the delivery section: the
+// schematic, the distance from home and the buttons to open the system map
+// and to set the home location.
+func (s *OrdersScreen) createStoreLocation(order tesla.DetailedOrder, store tesla.Store) fyne.CanvasObject {
+	openButton := widget.NewButtonWithIcon(i18n.Text("open_in_maps"), theme.SearchIcon(), func() {
+		link, err := url.Parse(mapURL(store, runtime.GOOS))
+		if err == nil {
+			err = s.app.OpenURL(link)
+		}
+		if err != nil {
+			dialog.ShowError(err, s.window)
+		}
+	})
+	homeButton := widget.NewButtonWithIcon(i18n.Text("home_location"), theme.HomeIcon(), s.showHomeDialog)
+	buttons := container.NewHBox(openButton, homeButton)
+
+	if !store.HasLocation() {
+		message := widget.NewLabelWithStyle(i18n.Text("no_store_location"), fyne.TextAlignLeading, fyne.TextStyle{Italic: true})
+		return container.NewVBox(message, buttons)
+	}
+
+	var distance string
+	form := widget.NewForm()
+	if !s.home.IsZero() {
+		market := s.sessionFor(order).manager.MarketFor(order)
+		distance = formatDistance(s.home.DistanceKm(store.Location()), market.UsesMiles())
+		label := distance
+		// A distance to the town centre can be off by the size of the town.
+		if store.Approximate {
+			distance = "≈ " + distance
+			label = fmt.Sprintf("%s (%s)", distance, i18n.Text("approximate_distance"))
+		}
+		form.Append(i18n.Text("distance_from_home"), widget.NewLabel(label))
+	}
+
+	objects := []fyne.CanvasObject{s.createDeliveryMap(store, distance)}
+	if len(form.Items) > 0 {
+		objects = append(objects, form)
+	}
+	return container.NewVBox(append(objects, buttons)...)
+}
+
+// showHomeDialog edits the home location the delivery distance is measured
+// from. Clearing the coordinates removes it.
+func (s *OrdersScreen) showHomeDialog() {
+	addressEntry := widget.NewEntry()
+	addressEntry.SetText(s.home.Label)
+
+	coordinatesEntry := widget.NewEntry()
+	coordinatesEntry.SetPlaceHolder(i18n.Text("home_coordinates_hint"))
+	if !s.home.IsZero() {
+		coordinatesEntry.SetText(fmt.Sprintf("%.6f, %.6f", s.home.Latitude, s.home.Longitude))
+	}
+
+	hint := widget.NewLabelWithStyle(i18n.Text("home_location_hint"), fyne.TextAlignLeading, fyne.TextStyle{Italic: true})
+	hint.Wrapping = fyne.TextWrapWord
+
+	dialog.ShowForm(
+		i18n.Text("home_location"),
+		i18n.Text("save"),
+		i18n.Text("cancel"),
+		[]*widget.FormItem{
+			widget.NewFormItem(i18n.Text("home_address"), addressEntry),
+			widget.NewFormItem(i18n.Text("home_coordinates"), coordinatesEntry),
+			widget.NewFormItem("", hint),
+		},
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+
+			var home tesla.Location
+			if strings.TrimSpace(coordinatesEntry.Text) != "" {
+				latitude, longitude, err := tesla.ParseCoordinates(coordinatesEntry.Text)
+				if err != nil {
+					dialog.ShowError(err, s.window)
+					return
+				}
+				home = tesla.Location{Label: strings.TrimSpace(addressEntry.Text), Latitude: latitude, Longitude: longitude}
+			}
+
+			if err := tesla.SaveHome(tesla.HomeFile(), home); err != nil {
+				dialog.ShowError(err, s.window)
+				return
+			}
+			s.home = home
+
+			if s.currentOrderDetail.Order.ReferenceNumber != "" {
+				s.showOrderDetails(s.currentOrderDetail)
+			}
+		},
+		s.window,
+	)
+}
@@ -0,0 +1,71 @@
+package gui
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+func TestProjectPoints(t *testing.T) {
+	size := fyne.NewSize(200, 100)
+
+	single := projectPoints([]tesla.Location{{Latitude: 48.1, Longitude: 11.5}}, size, 10)
+	if len(single) != 1 || single[0] != fyne.NewPos(100, 50) {
+		t.Errorf("single point = %v, want the centre", single)
+	}
+
+	// Home north-west of the center: home ends up top left.
+	positions := projectPoints([]tesla.Location{
+		{Latitude: 48.0, Longitude: 11.6},
+		{Latitude: 48.2, Longitude: 11.4},
+	}, size, 10)
+	center, home := positions[0], positions[1]
+	if !(home.X < center.X && home.Y < center.Y) {
+		t.Errorf("home %v is not north-west of center %v", home, center)
+	}
+	for _, p := range positions {
+		if p.X < 10 || p.X > 190 || p.Y < 10 || p.Y > 90 {
+			t.Errorf("point %v is outside the margin", p)
+		}
+	}
+	if home.Y != 10 || center.Y != 90 {
+		t.Errorf("north-south spread %v, %v does not fill the height", home, center)
+	}
+}
+
+func TestMapURL(t *testing.T) {
+	exact := tesla.Store{Name: "Tesla Test", Latitude: 48.1, Longitude: 11.5}
+	approximate := tesla.Store{Name: "Tesla Test", City: "München", Address: "Hauptstr. 1", Latitude: 48.1, Longitude: 11.5, Approximate: true}
+	// Like the bundled stores: town coordinates and no address.
+	townLevel := tesla.Store{Name: "München Freiham", City: "München", Country: "DE", Latitude: 48.14, Longitude: 11.58, Approximate: true}
+
+	tests := []struct {
+		store tesla.Store
+		goos  string
+		want  string
+	}{
+		{exact, "darwin", "https://maps.apple.com/?ll=48.100000,11.500000&q=Tesla+Test"},
+		{exact, "windows", "bingmaps:?collection=point.48.100000_11.500000_Tesla%20Test&lvl=14"},
+		{exact, "linux", "https://www.openstreetmap.org/?mlat=48.100000&mlon=11.500000#map=14/48.100000/11.500000"},
+		{approximate, "linux", "https://www.openstreetmap.org/search?query=Tesla+Test%2C+Hauptstr.+1%2C+M%C3%BCnchen"},
+		{approximate, "darwin", "https://maps.apple.com/?q=Tesla+Test%2C+Hauptstr.+1%2C+M%C3%BCnchen"},
+		{townLevel, "linux", "https://www.openstreetmap.org/search?query=M%C3%BCnchen+Freiham%2C+M%C3%BCnchen%2C+DE"},
+		{townLevel, "windows", "bingmaps:?q=M%C3%BCnchen+Freiham%2C+M%C3%BCnchen%2C+DE"},
+	}
+	for _, tt := range tests {
+		if got := mapURL(tt.store, tt.goos); got != tt.want {
+			t.Errorf("mapURL(%+v, %s) = %q, want %q", tt.store, tt.goos, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDistance(t *testing.T) {
+	if got := formatDistance(3.25, false); got != "3.2 km" {
+		t.Errorf("formatDistance(3.25, km) = %q", got)
+	}
+	if got := formatDistance(160.9344, true); got != "100 mi" {
+		t.Errorf("formatDistance(160.9, mi) = %q", got)
+	}
+}
@@ -0,0 +1,86 @@
+package gui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/i18n"
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+// historyFieldLabels maps the timeline fields to their i18n keys.
+var historyFieldLabels = map[string]string{
+	"Status":              "status",
+	"VIN":                 "vin",
+	"DeliveryWindow":      "delivery_window",
+	"ETAToDeliveryCenter": "estimated_arrival",
+	"DeliveryAppointment": "delivery_appointment",
+	"AmountDue":           "remaining_amount",
+	tesla.StageField:      "stage",
+	tesla.OrderField:      "order",
+}
+
+// createHistoryContainer builds the timeline of recorded changes for an
+// order, newest first.
+func (s *OrdersScreen) createHistoryContainer(order tesla.DetailedOrder, title *canvas.Text) fyne.CanvasObject {
+	historyContainer := container.NewVBox(title, widget.NewSeparator())
+
+	entries, err := s.sessionFor(order).manager.LoadHistory(order.Order.ReferenceNumber)
+	if err != nil {
+		fmt.Printf("Error loading order history: %v\n", err)
+	}
+
+	if len(entries) == 0 {
+		historyContainer.Add(container.NewPadded(
+			widget.NewLabelWithStyle(i18n.Text("no_history"), fyne.TextAlignLeading, fyne.TextStyle{Italic: true}),
+		))
+		return historyContainer
+	}
+
+	historyForm := widget.NewForm()
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		historyForm.Append(entry.Time.Local().Format("2006-01-02 15:04"), widget.NewLabel(formatHistoryEntry(entry)))
+	}
+	historyContainer.Add(container.NewPadded(historyForm))
+
+	return historyContainer
+}
+
+func formatHistoryEntry(entry tesla.HistoryEntry) string {
+	label := entry.Field
+	if key, ok := historyFieldLabels[entry.Field]; ok {
+		label = i18n.Text(key)
+	}
+
+	oldValue, newValue := entry.OldValue, entry.NewValue
+	if entry.Field == tesla.StageField {
+		if oldValue != "" {
+			oldValue = stageName(tesla.Stage(oldValue))
+		}
+		newValue = stageName(tesla.Stage(newValue))
+	}
+	if entry.Field == tesla.OrderField && newValue == tesla.OrderRemoved {
+		newValue = i18n.Text("order_removed")
+	}
+	if oldValue == "" {
+		oldValue = "-"
+	}
+	if newValue == "" {
+		newValue = "-"
+	}
+
+	return fmt.Sprintf("%s: %s → %s", label, oldValue, newValue)
+}
+
+func newSectionTitle(text string) *canvas.Text {
+	title := canvas.NewText(text, theme.ForegroundColor())
+	title.TextStyle = fyne.TextStyle{Bold: true}
+	title.TextSize = theme.TextSize() * 1.1
+	return title
+}
@@ -0,0 +1,71 @@
+package gui
+
+import (
+	"image/color"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/i18n"
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+func stageName(stage tesla.Stage) string {
+	return i18n.Text("stage_" + string(stage))
+}
+
+// stageProgressText is the compact stepper of the order list: one dot per
+// stage, filled up to the current one, followed by its name.
+func stageProgressText(stage tesla.Stage) string {
+	current := stage.Index()
+	var dots strings.Builder
+	for i := range tesla.Stages {
+		if i <= current {
+			dots.WriteString("●")
+		} else {
+			dots.WriteString("○")
+		}
+	}
+	return dots.String() + " " + stageName(stage)
+}
+
+// createStageStepper shows every lifecycle stage, marking the ones the
+// order has reached and, with the times recorded in its history, when.
+func (s *OrdersScreen) createStageStepper(order tesla.DetailedOrder, reached map[tesla.Stage]time.Time) fyne.CanvasObject {
+	current := tesla.OrderStage(order, time.Now()).Index()
+
+	steps := container.NewGridWithColumns(len(tesla.Stages))
+	for i, stage := range tesla.Stages {
+		fill := theme.DisabledColor()
+		if i <= current {
+			fill = theme.PrimaryColor()
+		}
+		dot := canvas.NewCircle(fill)
+		if i == current {
+			dot.StrokeColor = theme.ForegroundColor()
+			dot.StrokeWidth = 2
+		}
+		// The circle takes the size of the stack it is in.
+		dotBox := canvas.NewRectangle(color.Transparent)
+		dotBox.SetMinSize(fyne.NewSize(14, 14))
+
+		name := widget.NewLabelWithStyle(stageName(stage), fyne.TextAlignCenter, fyne.TextStyle{Bold: i == current})
+		name.Wrapping = fyne.TextWrapWord
+
+		step := container.NewVBox(container.NewCenter(container.NewStack(dotBox, dot)), name)
+		if at, ok := reached[stage]; ok && i <= current {
+			date := canvas.NewText(at.Local().Format("2006-01-02"), theme.DisabledColor())
+			date.TextSize = theme.CaptionTextSize()
+			date.Alignment = fyne.TextAlignCenter
+			step.Add(date)
+		}
+		steps.Add(step)
+	}
+
+	return steps
+}
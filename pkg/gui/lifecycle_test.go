@@ -0,0 +1,22 @@
+package gui
+
+import (
+	"testing"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/i18n"
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+func TestStageProgressText(t *testing.T) {
+	if got, want := stageProgressText(tesla.StageVINAssigned), "●●●○○○○ "+i18n.Text("stage_vin_assigned"); got != want {
+		t.Errorf("stageProgressText() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatStageHistoryEntry(t *testing.T) {
+	entry := tesla.HistoryEntry{Field: tesla.StageField, OldValue: string(tesla.StageBooked), NewValue: string(tesla.StageVINAssigned)}
+	want := i18n.Text("stage") + ": " + i18n.Text("stage_booked") + " → " + i18n.Text("stage_vin_assigned")
+	if got := formatHistoryEntry(entry); got != want {
+		t.Errorf("formatHistoryEntry() = %q, want %q", got, want)
+	}
+}
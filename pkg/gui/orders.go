@@ -1,12 +1,13 @@
 package gui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"image/color"
-	"os"
 	"reflect"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -26,11 +27,17 @@ import (
 type OrdersScreen struct {
 	window           fyne.Window
 	app              fyne.App
-	teslaAuth        *tesla.TeslaAuth
-	orderManager     *tesla.OrderManager
+	accounts         *tesla.AccountStore
+	sessions         []*accountSession
+	orderAccounts    map[string]*accountSession
+	selectedAccount  string
 	ordersList       *widget.List
 	detailsContainer *fyne.Container
 	orders           []tesla.DetailedOrder
+	allOrders        []tesla.DetailedOrder
+	// stageTimes are when each order reached its stages, read from the
+	// history with the orders so the details do not read it.
+	stageTimes       map[string]map[tesla.Stage]time.Time
 	refreshTimer     *time.Timer
 	refreshInterval  time.Duration
 	isAutoRefresh    bool
@@ -38,36 +45,63 @@ type OrdersScreen struct {
 	lastRefreshTime  time.Time
 	refreshStatusLabel *widget.Label
 	changedFields    map[string]bool
-	onLogout         func() 
+	onLogout         func(account tesla.Account)
+	onAddAccount     func(account tesla.Account)
+	fetchMu          sync.Mutex
+	cancelFetch      context.CancelFunc
 	
 	
 	titleLabel      *widget.Label
+	profileLabel    *widget.Label
 	logoutButton    *widget.Button
 	refreshButton   *widget.Button
 	autoRefreshLabel *widget.Label
 	langSelect      *widget.Select
 	noOrdersLabel   *widget.Label
+	accountLabel    *widget.Label
+	accountSelect   *widget.Select
+	addAccountButton *widget.Button
+	marketButton    *widget.Button
 	
 	
 	mainDetailTitle *widget.Label
 	orderTitles     []*canvas.Text
 	currentOrderDetail tesla.DetailedOrder
+	detailTab       int
+	home            tesla.Location
 }
 
 
-func NewOrdersScreen(app fyne.App, window fyne.Window, teslaAuth *tesla.TeslaAuth, onLogout func()) *OrdersScreen {
-	orderManager := tesla.NewOrderManager(teslaAuth)
+func NewOrdersScreen(app fyne.App, window fyne.Window, accounts *tesla.AccountStore, onLogout func(account tesla.Account), onAddAccount func(account tesla.Account)) *OrdersScreen {
+	sessions := make([]*accountSession, 0, len(accounts.Accounts))
+	for _, account := range accounts.Accounts {
+		sessions = append(sessions, newAccountSession(account))
+	}
+	
+	selectedAccount := ""
+	if len(sessions) == 1 {
+		selectedAccount = sessions[0].account.Name
+	}
+	
+	home, err := tesla.LoadHome(tesla.HomeFile())
+	if err != nil {
+		fmt.Printf("Error loading home location: %v\n", err)
+	}
 	
 	return &OrdersScreen{
 		app:             app,
 		window:          window,
-		teslaAuth:       teslaAuth,
-		orderManager:    orderManager,
+		accounts:        accounts,
+		sessions:        sessions,
+		orderAccounts:   make(map[string]*accountSession),
+		selectedAccount: selectedAccount,
 		refreshInterval: time.Minute * 5, 
 		isAutoRefresh:   false,
 		lastRefreshTime: time.Now(),
 		changedFields:   make(map[string]bool),
 		onLogout:        onLogout,
+		onAddAccount:    onAddAccount,
+		home:            home,
 	}
 }
 
@@ -78,14 +112,7 @@ func (s *OrdersScreen) Show() {
 	fyne.Do(func() {
 		s.window.SetContent(content)
 		s.window.SetTitle(i18n.Text("app_title"))
-	})
-	
-	s.fetchOrders()
-	
-	s.window.Canvas().SetOnTypedKey(func(k *fyne.KeyEvent) {
-		if k.Name == fyne.KeyEscape && s.refreshTimer != nil {
-			s.refreshTimer.Stop()
-		}
+		s.PerformInitialSetup()
 	})
 }
 
@@ -123,13 +150,12 @@ func (s *OrdersScreen) GetContent() fyne.CanvasObject {
 			i18n.Text("logout_confirmation_message"),
 			func(confirmed bool) {
 				if confirmed {
-					if s.refreshTimer != nil {
-						s.refreshTimer.Stop()
-					}
-					if err := os.Remove(tesla.TokenFile); err != nil {
+					s.stopRefresh()
+					session := s.currentSession()
+					if err := session.auth.DeleteTokens(); err != nil {
 						fmt.Printf("Error removing token file: %v\n", err)
 					}
-					s.onLogout()
+					s.onLogout(session.account)
 				}
 			},
 			s.window,
@@ -152,10 +178,15 @@ func (s *OrdersScreen) GetContent() fyne.CanvasObject {
 	)
 	
 	s.titleLabel = widget.NewLabelWithStyle(i18n.Text("orders_title"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	s.profileLabel = widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Italic: true})
+	s.updateProfileLabel()
 	topBar := container.NewPadded(
 		container.NewVBox(
 			container.NewHBox(
 				s.titleLabel,
+				s.profileLabel,
+				layout.NewSpacer(),
+				s.createAccountControls(),
 				layout.NewSpacer(),
 				langControls,
 				layout.NewSpacer(),
@@ -192,12 +223,14 @@ func (s *OrdersScreen) GetContent() fyne.CanvasObject {
 func (s *OrdersScreen) PerformInitialSetup() {
 	// Call fetchOrders to populate the list when the screen is first shown
 	s.fetchOrders()
-	// Set up other initial configurations like key listeners
+	// Escape and closing the window stop the auto refresh and cancel a
+	// refresh in flight.
 	s.window.Canvas().SetOnTypedKey(func(k *fyne.KeyEvent) {
-		if k.Name == fyne.KeyEscape && s.refreshTimer != nil {
-			s.refreshTimer.Stop()
+		if k.Name == fyne.KeyEscape {
+			s.stopRefresh()
 		}
 	})
+	s.window.SetOnClosed(s.stopRefresh)
 	s.updateLanguageUI() // Ensure UI is updated with current language
 }
 
@@ -211,10 +244,7 @@ func (s *OrdersScreen) playNotificationSound() {
 
 func (s *OrdersScreen) handleRefreshIntervalChange(selected string) {
 	
-	if s.refreshTimer != nil {
-		s.refreshTimer.Stop()
-		s.refreshTimer = nil
-	}
+	s.stopRefresh()
 	
 	
 	switch selected {
@@ -246,6 +276,22 @@ func (s *OrdersScreen) handleRefreshIntervalChange(selected string) {
 }
 
 
+// stopRefresh stops the auto refresh timer and cancels a refresh in flight.
+func (s *OrdersScreen) stopRefresh() {
+	if s.refreshTimer != nil {
+		s.refreshTimer.Stop()
+		s.refreshTimer = nil
+	}
+	
+	s.fetchMu.Lock()
+	if s.cancelFetch != nil {
+		s.cancelFetch()
+		s.cancelFetch = nil
+	}
+	s.fetchMu.Unlock()
+}
+
+
 func (s *OrdersScreen) startRefreshTimer() {
 	if s.refreshTimer != nil {
 		s.refreshTimer.Stop()
@@ -274,6 +320,8 @@ func (s *OrdersScreen) createOrdersList() *widget.List {
 					widget.NewLabelWithStyle("Model", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 					widget.NewLabel("Reference No"),
 					widget.NewLabel("Status"),
+					widget.NewLabel("Stage"),
+					widget.NewLabel("Account"),
 					widget.NewSeparator(),
 				),
 			)
@@ -287,13 +335,28 @@ func (s *OrdersScreen) createOrdersList() *widget.List {
 			
 			
 			modelLabel := container.Objects[0].(*widget.Label)
-			modelLabel.SetText(fmt.Sprintf("Model: %s", order.Order.ModelCode))
+			modelLabel.SetText(fmt.Sprintf("Model: %s", tesla.Options().ModelName(order.Order.ModelCode)))
 			
 			refLabel := container.Objects[1].(*widget.Label)
 			refLabel.SetText(fmt.Sprintf("Ref: %s", order.Order.ReferenceNumber))
 			
 			statusLabel := container.Objects[2].(*widget.Label)
-			statusLabel.SetText(fmt.Sprintf("Status: %s", order.Order.OrderStatus))
+			statusText := fmt.Sprintf("Status: %s", order.Order.OrderStatus)
+			if order.Stale {
+				statusText += " (" + i18n.Text("stale_details") + ")"
+			}
+			statusLabel.SetText(statusText)
+			
+			stageLabel := container.Objects[3].(*widget.Label)
+			stageLabel.SetText(stageProgressText(tesla.OrderStage(order, time.Now())))
+			
+			accountLabel := container.Objects[4].(*widget.Label)
+			if len(s.sessions) > 1 {
+				accountLabel.SetText(fmt.Sprintf("%s: %s", i18n.Text("account"), s.sessionFor(order).label()))
+				accountLabel.Show()
+			} else {
+				accountLabel.Hide()
+			}
 		},
 	)
 	
@@ -310,119 +373,17 @@ func (s *OrdersScreen) createOrdersList() *widget.List {
 }
 
 
-func (s *OrdersScreen) processDifferences(differences []string, newOrders []tesla.DetailedOrder) {
+func (s *OrdersScreen) processChanges(changes []tesla.Change) {
 	
 	s.changedFields = make(map[string]bool)
 	
 	
-	for _, diff := range differences {
-		
-		if orderRef, field, found := extractOrderFieldFromDiff(diff, s.orders); found {
-			
-			key := fmt.Sprintf("%s_%s", field, orderRef)
+	for _, change := range changes {
+		for _, field := range change.Fields() {
+			key := fmt.Sprintf("%s_%s", field, change.ReferenceNumber)
 			s.changedFields[key] = true
 		}
 	}
-	
-	
-	if len(s.changedFields) == 0 && len(newOrders) > 0 {
-		
-		orderRef := newOrders[0].Order.ReferenceNumber
-		s.changedFields["Status_"+orderRef] = true
-		s.changedFields["VIN_"+orderRef] = true
-		s.changedFields["DeliveryWindow_"+orderRef] = true
-	}
-}
-
-
-func extractOrderFieldFromDiff(diff string, orders []tesla.DetailedOrder) (string, string, bool) {
-	var orderRef string
-	
-	
-	for i := 0; i < len(diff)-6; i++ {
-		if i+6 <= len(diff) && diff[i:i+2] == "RN" {
-			potential := diff[i : i+8] 
-			if isReferenceNumber(potential) {
-				orderRef = potential
-				break
-			}
-		}
-	}
-	
-	
-	if orderRef == "" && len(orders) > 0 {
-		orderRef = orders[0].Order.ReferenceNumber
-	}
-	
-	
-	if containsAny(diff, []string{"modelCode", "model"}) {
-		return orderRef, "Model", true
-	}
-	
-	
-	if containsAny(diff, []string{"orderStatus", "status", "Status"}) {
-		return orderRef, "Status", true
-	}
-	
-	
-	if containsAny(diff, []string{"vin", "VIN"}) {
-		return orderRef, "VIN", true
-	}
-	
-	
-	if containsAny(diff, []string{"vehicleOdometer", "odometer", "Odometer"}) {
-		return orderRef, "Odometer", true
-	}
-	
-	
-	if containsAny(diff, []string{"reservationDate", "reservation"}) {
-		return orderRef, "ReservationDate", true
-	}
-	
-	
-	if containsAny(diff, []string{"orderBookedDate", "orderDate"}) {
-		return orderRef, "OrderBookedDate", true
-	}
-	
-	
-	if containsAny(diff, []string{"vehicleRoutingLocation", "routingLocation", "location"}) {
-		return orderRef, "RoutingLocation", true
-	}
-	
-	
-	if containsAny(diff, []string{"deliveryWindow", "delivery", "window"}) {
-		return orderRef, "DeliveryWindow", true
-	}
-	
-	
-	if containsAny(diff, []string{"etaToDeliveryCenter", "eta", "ETA"}) {
-		return orderRef, "ETAToDeliveryCenter", true
-	}
-	
-	
-	if containsAny(diff, []string{"apptDateTime", "appointment", "Appointment"}) {
-		return orderRef, "DeliveryAppointment", true
-	}
-	
-	return "", "", false
-}
-
-
-func isReferenceNumber(str string) bool {
-	if len(str) >= 8 && str[0:2] == "RN" {
-		return true
-	}
-	return false
-}
-
-
-func containsAny(text string, substrs []string) bool {
-	for _, substr := range substrs {
-		if strings.Contains(strings.ToLower(text), strings.ToLower(substr)) {
-			return true
-		}
-	}
-	return false
 }
 
 
@@ -450,7 +411,7 @@ func (s *OrdersScreen) showOrderDetails(order tesla.DetailedOrder) {
 	
 	s.currentOrderDetail = order
 	
-	info := s.orderManager.ExtractOrderInfo(order)
+	info := s.sessionFor(order).manager.ExtractOrderInfo(order)
 	
 	
 	titleStyle := fyne.TextStyle{Bold: true}
@@ -474,7 +435,11 @@ func (s *OrdersScreen) showOrderDetails(order tesla.DetailedOrder) {
 	s.orderTitles = append(s.orderTitles, orderTitle)
 	
 	
-	orderForm.Append(i18n.Text("model"), s.createHighlightedLabel(info["Model"], "Model_"+order.Order.ReferenceNumber))
+	modelText := info["Model"]
+	if name := tesla.Options().ModelName(order.Order.ModelCode); name != order.Order.ModelCode {
+		modelText = fmt.Sprintf("%s (%s)", name, order.Order.ModelCode)
+	}
+	orderForm.Append(i18n.Text("model"), s.createHighlightedLabel(modelText, "Model_"+order.Order.ReferenceNumber))
 	
 	
 	orderForm.Append(i18n.Text("order_number"), widget.NewLabel(info["OrderID"]))
@@ -484,10 +449,11 @@ func (s *OrdersScreen) showOrderDetails(order tesla.DetailedOrder) {
 	
 	
 	orderForm.Append(i18n.Text("vin"), s.createHighlightedLabel(info["VIN"], "VIN_"+order.Order.ReferenceNumber))
+	appendVINDetails(orderForm, order.Order.VIN)
 	
 	
 	kmText := fmt.Sprintf("%s %s", info["VehicleOdometer"], info["VehicleOdometerType"])
-	orderForm.Append(i18n.Text("vehicle_odometer"), s.createHighlightedLabel(kmText, "Odometer_"+order.Order.ReferenceNumber))
+	orderForm.Append(i18n.Text("vehicle_odometer"), s.createHighlightedLabel(kmText, "VehicleOdometer_"+order.Order.ReferenceNumber))
 	
 	
 	orderContainer.Add(orderTitle)
@@ -527,14 +493,20 @@ func (s *OrdersScreen) showOrderDetails(order tesla.DetailedOrder) {
 	deliveryTitle.TextSize = theme.TextSize() * sectionTitleSize
 	s.orderTitles = append(s.orderTitles, deliveryTitle)
 	
-	routingLocationID, _ := strconv.Atoi(info["VehicleRoutingLocation"])
-	teslaStore := tesla.GetTeslaStoreByID(routingLocationID)
-	
-	locationText := fmt.Sprintf("%s (%s)", info["VehicleRoutingLocation"], teslaStore.Label)
+	locationText := info["VehicleRoutingLocation"]
+	var storeLocation fyne.CanvasObject
+	if routingLocation := tesla.RoutingLocation(order); routingLocation != 0 {
+		storeName := i18n.Text("unknown_store")
+		if store, ok := tesla.Stores().Lookup(routingLocation); ok {
+			storeName = store.Name
+			storeLocation = s.createStoreLocation(order, store)
+		}
+		locationText = fmt.Sprintf("%d (%s)", routingLocation, storeName)
+	}
 	
 	
 	deliveryForm.Append(i18n.Text("delivery_location"), 
-		s.createHighlightedLabel(locationText, "RoutingLocation_"+order.Order.ReferenceNumber))
+		s.createHighlightedLabel(locationText, "VehicleRoutingLocation_"+order.Order.ReferenceNumber))
 	
 	
 	deliveryForm.Append(i18n.Text("delivery_window"), 
@@ -552,59 +524,17 @@ func (s *OrdersScreen) showOrderDetails(order tesla.DetailedOrder) {
 	deliveryContainer.Add(deliveryTitle)
 	deliveryContainer.Add(widget.NewSeparator())
 	deliveryContainer.Add(container.NewPadded(deliveryForm))
-	
-	
-	paymentContainer := container.NewVBox()
-	paymentForm := widget.NewForm()
-	
-	
-	paymentTitle := canvas.NewText(i18n.Text("payment_details"), theme.ForegroundColor())
-	paymentTitle.TextStyle = titleStyle
-	paymentTitle.TextSize = theme.TextSize() * sectionTitleSize
-	s.orderTitles = append(s.orderTitles, paymentTitle)
-	
-	
-	
-	var reservationAmount float64 = 0
-	
-	
-	if registration, ok := order.Details.Tasks["registration"].(map[string]interface{}); ok {
-		if orderDetails, ok := registration["orderDetails"].(map[string]interface{}); ok {
-			if amount, ok := orderDetails["reservationAmountReceived"].(float64); ok {
-				reservationAmount = amount
-				paymentForm.Append(i18n.Text("reservation_amount"), widget.NewLabel(formatCurrency(amount, "TRY")))
-			}
-		}
+	if storeLocation != nil {
+		deliveryContainer.Add(container.NewPadded(storeLocation))
 	}
 	
-	if finalPayment, ok := order.Details.Tasks["finalPayment"].(map[string]interface{}); ok {
-		
-		var totalAmount float64 = reservationAmount
-		var amountDue float64
-		
-		if finalPayment["amountDue"] != nil {
-			if amount, ok := finalPayment["amountDue"].(float64); ok {
-				amountDue = amount
-				totalAmount += amount
-			}
-		}
-		
-		
-		paymentForm.Append(i18n.Text("total_price"), widget.NewLabel(formatCurrency(totalAmount, "TRY")))
-		
-		
-		paymentForm.Append(i18n.Text("remaining_amount"), widget.NewLabel(formatCurrency(amountDue, "TRY")))
-		
-		
-		if status, ok := finalPayment["status"].(string); ok {
-			paymentForm.Append(i18n.Text("payment_status"), widget.NewLabel(formatPaymentStatus(status)))
-		}
-	}
+	
+	paymentContainer := s.createPricingContainer(order)
 	
 	
-	paymentContainer.Add(paymentTitle)
-	paymentContainer.Add(widget.NewSeparator())
-	paymentContainer.Add(container.NewPadded(paymentForm))
+	historyTitle := newSectionTitle(i18n.Text("order_history"))
+	s.orderTitles = append(s.orderTitles, historyTitle)
+	historyContainer := s.createHistoryContainer(order, historyTitle)
 	
 	verticalSpacer := func() fyne.CanvasObject {
 		rect := canvas.NewRectangle(color.Transparent)
@@ -613,37 +543,53 @@ func (s *OrdersScreen) showOrderDetails(order tesla.DetailedOrder) {
 	}
 	
 	
-	content := container.NewVBox(
+	objects := []fyne.CanvasObject{
 		s.mainDetailTitle,
 		widget.NewSeparator(), 
+	}
+	
+	if order.Stale {
+		staleLabel := widget.NewLabelWithStyle(i18n.Text("stale_details_message"), fyne.TextAlignCenter, fyne.TextStyle{Italic: true})
+		staleLabel.Wrapping = fyne.TextWrapWord
+		objects = append(objects, staleLabel)
+	}
+	
+	
+	summaryContent := container.NewVBox(
+		s.createStageStepper(order, s.stageTimes[order.Order.ReferenceNumber]),
+		widget.NewSeparator(),
 		orderContainer,
 		verticalSpacer(),
 		reservationContainer,
 		verticalSpacer(),
-		deliveryContainer,
-		verticalSpacer(),
-		paymentContainer,
+		historyContainer,
 	)
 	
 	
-	scrollContent := container.NewScroll(content)
+	tabs := container.NewAppTabs(
+		container.NewTabItem(i18n.Text("tab_summary"), container.NewScroll(summaryContent)),
+		container.NewTabItem(i18n.Text("tab_tasks"), container.NewScroll(s.createChecklistContainer(order))),
+		container.NewTabItem(i18n.Text("tab_configuration"), container.NewScroll(s.createConfigurationContainer(order))),
+		container.NewTabItem(i18n.Text("tab_delivery"), container.NewScroll(deliveryContainer)),
+		container.NewTabItem(i18n.Text("tab_pricing"), container.NewScroll(paymentContainer)),
+	)
+	// Keep the tab the user was looking at when the details are redrawn.
+	tabs.SelectIndex(s.detailTab)
+	tabs.OnSelected = func(*container.TabItem) {
+		s.detailTab = tabs.SelectedIndex()
+	}
 	
 	
-	paddedScrollContent := container.NewPadded(scrollContent)
+	detailContent := container.NewPadded(container.NewBorder(container.NewVBox(objects...), nil, nil, nil, tabs))
 	
 	
 	fyne.Do(func() {
-		s.detailsContainer.Objects = []fyne.CanvasObject{paddedScrollContent} 
+		s.detailsContainer.Objects = []fyne.CanvasObject{detailContent} 
 		s.detailsContainer.Refresh()
 	})
 }
 
 
-func formatCurrency(amount float64, currency string) string {
-	return fmt.Sprintf("%.2f %s", amount, currency)
-}
-
-
 func formatPaymentStatus(status string) string {
 	switch status {
 	case "MAKE_YOUR_FINAL_PAYMENT":
@@ -660,67 +606,150 @@ func formatPaymentStatus(status string) string {
 
 func (s *OrdersScreen) fetchOrders() {
 	
+	// A new refresh supersedes the one in flight.
+	ctx, cancel := context.WithCancel(context.Background())
+	s.fetchMu.Lock()
+	if s.cancelFetch != nil {
+		s.cancelFetch()
+	}
+	s.cancelFetch = cancel
+	s.fetchMu.Unlock()
+	
 	progress := dialog.NewProgressInfinite(i18n.Text("loading_orders"), i18n.Text("fetching_orders"), s.window)
 	progress.Show()
 	
 	
 	go func() {
+		defer cancel()
 		
-		oldOrders, _ := s.orderManager.LoadOrdersFromFile()
+		var allOrders []tesla.DetailedOrder
+		var allChanges []tesla.Change
+		var fetchErrors []string
+		orderAccounts := make(map[string]*accountSession)
 		
+		loggedIn := make([]*accountSession, 0, len(s.sessions))
+		for _, session := range s.sessions {
+			if session.hasTokens() {
+				loggedIn = append(loggedIn, session)
+			}
+		}
 		
-		newOrders, err := s.orderManager.GetDetailedOrders()
-		if err != nil {
+		for _, session := range loggedIn {
+			oldOrders, _ := session.manager.LoadOrdersFromFile()
+			
+			
+			newOrders, err := session.manager.GetDetailedOrders(ctx)
+			if ctx.Err() != nil {
+				fyne.Do(func() {
+					progress.Hide()
+				})
+				return
+			}
+			if errors.Is(err, tesla.ErrLoginRequired) {
+				if len(loggedIn) == 1 {
+					fyne.Do(func() {
+						progress.Hide()
+						s.stopRefresh()
+						s.onLogout(session.account)
+					})
+					return
+				}
+				fetchErrors = append(fetchErrors, accountLoginRequiredMessage(session.account))
+				allOrders = append(allOrders, cachedOrders(session, oldOrders, orderAccounts)...)
+				continue
+			}
+			if err != nil {
+				fetchErrors = append(fetchErrors, fmt.Sprintf(i18n.Text("error_fetching_orders"), err))
+				
+				// Orders whose details failed come back stale with their
+				// saved details; a failed order list falls back to the
+				// saved orders.
+				if newOrders == nil {
+					allOrders = append(allOrders, cachedOrders(session, oldOrders, orderAccounts)...)
+					continue
+				}
+			}
+			
+			
+			if oldOrders != nil && len(oldOrders) > 0 {
+				allChanges = append(allChanges, session.manager.CompareOrders(oldOrders, newOrders)...)
+			}
+			
+			
+			if err := session.manager.SaveOrdersToFile(newOrders); err != nil {
+				fetchErrors = append(fetchErrors, fmt.Sprintf(i18n.Text("error_saving_orders"), err))
+			}
+			
+			for _, order := range newOrders {
+				orderAccounts[order.Order.ReferenceNumber] = session
+			}
+			allOrders = append(allOrders, newOrders...)
+		}
+		
+		if len(fetchErrors) > 0 {
 			fyne.Do(func() {
-				progress.Hide()
 				fyne.CurrentApp().SendNotification(&fyne.Notification{
 					Title:   i18n.Text("error"),
-					Content: fmt.Sprintf(i18n.Text("error_fetching_orders"), err),
+					Content: strings.Join(fetchErrors, "\n"),
 				})
 			})
-			return
+			if len(allOrders) == 0 {
+				fyne.Do(func() {
+					progress.Hide()
+				})
+				return
+			}
 		}
 		
 		
-		fyne.Do(func() {
+		hasChanges := len(allChanges) > 0
+		if hasChanges {
+			s.playNotificationSound()
 			
-			s.orders = newOrders
-		})
-		
-		
-		hasChanges := false
-		var differences []string
-		
-		if oldOrders != nil && len(oldOrders) > 0 {
-			differences = s.orderManager.CompareOrders(oldOrders, newOrders)
-			if len(differences) > 0 {
-				hasChanges = true
-				
-				
-				s.processDifferences(differences, newOrders)
-				
-				
-				s.playNotificationSound()
-				
+			
+			fyne.Do(func() {
 				
-				fyne.Do(func() {
-					
-					fyne.CurrentApp().SendNotification(&fyne.Notification{
-						Title:   i18n.Text("changes"),
-						Content: i18n.Text("order_changes_detected"),
-					})
+				content := i18n.Text("order_changes_detected")
+				if assigned := vinAssignedText(allChanges); assigned != "" {
+					content += "\n" + assigned
+				}
+				fyne.CurrentApp().SendNotification(&fyne.Notification{
+					Title:   i18n.Text("changes"),
+					Content: content,
 				})
-			}
+			})
 		}
 		
 		
-		s.orderManager.SaveOrdersToFile(newOrders)
-		
-		
 		s.lastRefreshTime = time.Now()
 		
+		stageTimes := make(map[string]map[tesla.Stage]time.Time, len(allOrders))
+		for _, order := range allOrders {
+			referenceNumber := order.Order.ReferenceNumber
+			times, err := orderAccounts[referenceNumber].manager.RecordedStageTimes(referenceNumber)
+			if err != nil {
+				fmt.Printf("Error loading order history: %v\n", err)
+			}
+			stageTimes[referenceNumber] = times
+		}
+		
+		// A token refresh during the fetch may have updated the profiles.
+		profiles := make([]tesla.Profile, len(s.sessions))
+		for i, session := range s.sessions {
+			profiles[i] = session.account.Profile()
+		}
 		
 		fyne.Do(func() {
+			s.allOrders = allOrders
+			s.orderAccounts = orderAccounts
+			s.stageTimes = stageTimes
+			if hasChanges {
+				// Read by createHighlightedLabel, so only set on the UI thread.
+				s.processChanges(allChanges)
+			}
+			for i, session := range s.sessions {
+				session.profile = profiles[i]
+			}
 			
 			statusText := fmt.Sprintf("%s: %s", i18n.Text("last_refresh"), s.lastRefreshTime.Format("15:04:05"))
 			if hasChanges {
@@ -730,13 +759,7 @@ func (s *OrdersScreen) fetchOrders() {
 			
 			
 			progress.Hide()
-			s.ordersList.Refresh()
-			
-			
-			if len(s.orders) > 0 {
-				s.ordersList.Select(0)
-				s.showOrderDetails(s.orders[0])
-			}
+			s.applyAccountFilter()
 		})
 	}()
 }
@@ -822,6 +845,28 @@ func (s *OrdersScreen) updateLanguageUI() {
 	}
 	
 	
+	if s.accountLabel != nil {
+		s.accountLabel.SetText(i18n.Text("account") + ":")
+	}
+	
+	if s.addAccountButton != nil {
+		s.addAccountButton.SetText(i18n.Text("add_account"))
+	}
+	
+	if s.marketButton != nil {
+		s.marketButton.SetText(i18n.Text("market"))
+	}
+	
+	if s.accountSelect != nil {
+		s.accountSelect.Options = s.accountOptions()
+		if s.selectedAccount == "" {
+			s.accountSelect.SetSelected(i18n.Text("all_accounts"))
+		} else {
+			s.accountSelect.SetSelected(s.selectedAccount)
+		}
+	}
+	
+	
 	if s.refreshSelect != nil {
 		
 		newOptions := []string{
@@ -847,22 +892,7 @@ func (s *OrdersScreen) updateLanguageUI() {
 		s.mainDetailTitle.SetText(i18n.Text("order_details"))
 		
 		
-		if s.orderTitles != nil && len(s.orderTitles) > 0 {
-			if len(s.orderTitles) > 0 && s.orderTitles[0] != nil {
-				s.orderTitles[0].Text = i18n.Text("order_information")
-			}
-			if len(s.orderTitles) > 1 && s.orderTitles[1] != nil {
-				s.orderTitles[1].Text = i18n.Text("reservation_information")
-			}
-			if len(s.orderTitles) > 2 && s.orderTitles[2] != nil {
-				s.orderTitles[2].Text = i18n.Text("delivery_information")
-			}
-			if len(s.orderTitles) > 3 && s.orderTitles[3] != nil {
-				s.orderTitles[3].Text = i18n.Text("payment_details")
-			}
-		}
-		
-		
+		// showOrderDetails builds every section title from i18n.
 		if !reflect.DeepEqual(s.currentOrderDetail, tesla.DetailedOrder{}) {
 			s.showOrderDetails(s.currentOrderDetail)
 		}
@@ -0,0 +1,161 @@
+package gui
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/i18n"
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+// createPricingContainer builds the pricing tab: the price breakdown, the
+// payments made towards it and whether they add up to the amount due.
+func (s *OrdersScreen) createPricingContainer(order tesla.DetailedOrder) fyne.CanvasObject {
+	manager := s.sessionFor(order).manager
+	payment := manager.ExtractPayment(order)
+	market := manager.MarketFor(order)
+	format := func(amount float64) string {
+		return tesla.FormatAmount(amount, payment.Currency, market)
+	}
+	money := func(amount float64) fyne.CanvasObject {
+		return widget.NewLabel(format(amount))
+	}
+
+	paymentTitle := newSectionTitle(i18n.Text("payment_details"))
+	s.orderTitles = append(s.orderTitles, paymentTitle)
+	pricingContainer := container.NewVBox()
+
+	if payment.HasBreakdown() || payment.StatedTotal != 0 {
+		pricingTitle := newSectionTitle(i18n.Text("pricing_details"))
+		s.orderTitles = append(s.orderTitles, pricingTitle)
+
+		breakdownForm := widget.NewForm()
+		for _, item := range []struct {
+			key    string
+			amount float64
+		}{
+			{"base_price", payment.BasePrice},
+			{"options_price", payment.OptionsPrice},
+			{"destination_fee", payment.DestinationFee},
+			{"order_fee", payment.OrderFee},
+		} {
+			if item.amount != 0 {
+				breakdownForm.Append(i18n.Text(item.key), money(item.amount))
+			}
+		}
+		for _, incentive := range payment.Incentives {
+			label := i18n.Text("incentive")
+			if incentive.Description != "" {
+				label = fmt.Sprintf("%s (%s)", label, incentive.Description)
+			}
+			breakdownForm.Append(label, money(-incentive.Amount))
+		}
+		total := payment.StatedTotal
+		if payment.HasBreakdown() {
+			total = payment.Price()
+		}
+		breakdownForm.Append(i18n.Text("total_price"), money(total))
+
+		pricingContainer.Add(pricingTitle)
+		pricingContainer.Add(widget.NewSeparator())
+		pricingContainer.Add(container.NewPadded(breakdownForm))
+	}
+
+	paymentForm := widget.NewForm()
+	if payment.Reservation != 0 {
+		paymentForm.Append(i18n.Text("reservation_amount"), money(payment.Reservation))
+	}
+	for _, deposit := range payment.Deposits {
+		label := i18n.Text("deposit")
+		if deposit.Type != "" {
+			label = fmt.Sprintf("%s (%s)", label, deposit.Type)
+		}
+		paymentForm.Append(label, money(deposit.Amount))
+	}
+	if payment.TradeIn != 0 {
+		paymentForm.Append(i18n.Text("trade_in_credit"), money(payment.TradeIn))
+	}
+	if payment.Financed != 0 {
+		paymentForm.Append(i18n.Text("financed_amount"), money(payment.Financed))
+	}
+
+	if order.Details.TypedTasks().FinalPayment != nil {
+		if !payment.HasBreakdown() && payment.StatedTotal == 0 {
+			paymentForm.Append(i18n.Text("total_price"), money(payment.Total()))
+		}
+
+		amountDue := container.NewVBox(s.createHighlightedLabel(format(payment.AmountDue), "AmountDue_"+order.Order.ReferenceNumber))
+		if change, ok := s.lastAmountDueChange(order); ok {
+			amountDue.Add(widget.NewLabelWithStyle(amountDueChangeText(change, format), fyne.TextAlignLeading, fyne.TextStyle{Italic: true}))
+		}
+		paymentForm.Append(i18n.Text("remaining_amount"), amountDue)
+
+		if payment.Status != "" {
+			paymentForm.Append(i18n.Text("payment_status"), widget.NewLabel(formatPaymentStatus(payment.Status)))
+		}
+	}
+
+	pricingContainer.Add(paymentTitle)
+	pricingContainer.Add(widget.NewSeparator())
+	pricingContainer.Add(container.NewPadded(paymentForm))
+
+	if reconciliation, ok := payment.Reconcile(); ok {
+		pricingContainer.Add(container.NewPadded(reconciliationLabel(reconciliation, format)))
+	}
+
+	return pricingContainer
+}
+
+// reconciliationLabel tells whether the breakdown, the payments and the
+// amount due add up, and by how much they are off if not.
+func reconciliationLabel(r tesla.Reconciliation, format func(float64) string) fyne.CanvasObject {
+	text, icon := reconciliationText(r, format), theme.ConfirmIcon()
+	if !r.Balanced() {
+		icon = theme.WarningIcon()
+	}
+
+	label := widget.NewLabel(text)
+	label.Wrapping = fyne.TextWrapWord
+	return container.NewBorder(nil, nil, widget.NewIcon(icon), nil, label)
+}
+
+func reconciliationText(r tesla.Reconciliation, format func(float64) string) string {
+	switch {
+	case r.StatedTotal != 0 && math.Abs(r.StatedTotal-r.Price) >= 0.005:
+		return fmt.Sprintf(i18n.Text("stated_total_mismatch"), format(r.StatedTotal), format(r.Price))
+	case !r.Balanced():
+		return fmt.Sprintf(i18n.Text("pricing_mismatch"), format(r.ExpectedDue), format(r.Difference))
+	default:
+		return i18n.Text("pricing_reconciled")
+	}
+}
+
+// lastAmountDueChange returns the most recent recorded change of the amount
+// due. The value recorded when the order was first seen is not a change.
+func (s *OrdersScreen) lastAmountDueChange(order tesla.DetailedOrder) (tesla.HistoryEntry, bool) {
+	entries, err := s.sessionFor(order).manager.LoadHistory(order.Order.ReferenceNumber)
+	if err != nil {
+		fmt.Printf("Error loading order history: %v\n", err)
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Field == "AmountDue" && entries[i].OldValue != "" {
+			return entries[i], true
+		}
+	}
+	return tesla.HistoryEntry{}, false
+}
+
+func amountDueChangeText(change tesla.HistoryEntry, format func(float64) string) string {
+	previous := change.OldValue
+	if amount, err := strconv.ParseFloat(previous, 64); err == nil {
+		previous = format(amount)
+	}
+	return fmt.Sprintf(i18n.Text("amount_due_changed"), previous, change.Time.Local().Format("2006-01-02 15:04"))
+}
@@ -0,0 +1,40 @@
+package gui
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/i18n"
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+func TestReconciliationText(t *testing.T) {
+	format := func(amount float64) string { return fmt.Sprintf("%.0f", amount) }
+
+	tests := []struct {
+		name    string
+		payment tesla.Payment
+		want    string
+	}{
+		{"balanced", tesla.Payment{BasePrice: 40000, Reservation: 250, AmountDue: 39750}, i18n.Text("pricing_reconciled")},
+		{"amount due off", tesla.Payment{BasePrice: 40000, Reservation: 250, AmountDue: 40000}, fmt.Sprintf(i18n.Text("pricing_mismatch"), "39750", "250")},
+		{"stated total off", tesla.Payment{BasePrice: 40000, StatedTotal: 41000, AmountDue: 40000}, fmt.Sprintf(i18n.Text("stated_total_mismatch"), "41000", "40000")},
+	}
+	for _, tt := range tests {
+		r, _ := tt.payment.Reconcile()
+		if got := reconciliationText(r, format); got != tt.want {
+			t.Errorf("%s: reconciliationText() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAmountDueChangeText(t *testing.T) {
+	format := func(amount float64) string { return fmt.Sprintf("€%.2f", amount) }
+	at := time.Date(2026, 3, 1, 12, 30, 0, 0, time.Local)
+
+	got := amountDueChangeText(tesla.HistoryEntry{Time: at, Field: "AmountDue", OldValue: "44990.00", NewValue: "43990.00"}, format)
+	if want := fmt.Sprintf(i18n.Text("amount_due_changed"), "€44990.00", "2026-03-01 12:30"); got != want {
+		t.Errorf("amountDueChangeText() = %q, want %q", got, want)
+	}
+}
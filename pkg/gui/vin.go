@@ -0,0 +1,103 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/i18n"
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+// appendVINDetails adds what the VIN of the order encodes below the VIN
+// itself. Orders without a VIN, or with one that cannot be decoded, get
+// nothing.
+func appendVINDetails(form *widget.Form, vin string) {
+	decoded, err := tesla.DecodeVIN(vin)
+	if err != nil {
+		return
+	}
+
+	if !decoded.CheckDigitValid {
+		warning := widget.NewLabelWithStyle(i18n.Text("vin_check_digit_invalid"), fyne.TextAlignLeading, fyne.TextStyle{Italic: true})
+		warning.Importance = widget.WarningImportance
+		form.Append("", warning)
+	}
+	if decoded.Manufacturer != "" {
+		form.Append(i18n.Text("vin_manufacturer"), widget.NewLabel(decoded.Manufacturer))
+	}
+	if decoded.Model != "" {
+		form.Append(i18n.Text("vin_model"), widget.NewLabel(decoded.Model))
+	}
+	if decoded.ModelYear != 0 {
+		form.Append(i18n.Text("vin_model_year"), widget.NewLabel(fmt.Sprint(decoded.ModelYear)))
+	}
+	if decoded.Plant != "" {
+		form.Append(i18n.Text("vin_plant"), widget.NewLabel(decoded.Plant))
+	}
+	if decoded.Body != "" {
+		form.Append(i18n.Text("vin_body"), widget.NewLabel(decoded.Body))
+	}
+	form.Append(i18n.Text("vin_drivetrain"), widget.NewLabel(vinDrivetrain(decoded)))
+	form.Append(i18n.Text("vin_serial"), widget.NewLabel(decoded.Serial))
+}
+
+// vinDrivetrain describes the motor and battery codes, falling back to the
+// raw code for the ones the decoder does not know.
+func vinDrivetrain(v tesla.VINInfo) string {
+	motor, battery := v.Motor, v.Battery
+	if motor == "" {
+		motor = v.MotorCode
+	}
+	if battery == "" {
+		battery = v.BatteryCode
+	}
+	return fmt.Sprintf("%s / %s", motor, battery)
+}
+
+// vinSummaryText describes the vehicle in one line in the current language,
+// like tesla.VINInfo.Summary does in English for the notifiers.
+func vinSummaryText(v tesla.VINInfo) string {
+	var parts []string
+
+	vehicle := v.Model
+	if vehicle == "" {
+		vehicle = "Tesla"
+	}
+	if v.ModelYear != 0 {
+		vehicle = fmt.Sprintf("%d %s", v.ModelYear, vehicle)
+	}
+	parts = append(parts, vehicle)
+
+	if v.Motor != "" {
+		parts = append(parts, v.Motor)
+	}
+	if v.Plant != "" {
+		parts = append(parts, fmt.Sprintf(i18n.Text("vin_summary_built_in"), v.Plant))
+	}
+	parts = append(parts, fmt.Sprintf(i18n.Text("vin_summary_serial"), v.Serial))
+	if !v.CheckDigitValid {
+		parts = append(parts, i18n.Text("vin_summary_check_digit"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// vinAssignedText describes the VINs assigned by changes, one order per
+// line, for the change notification. It is empty if none was assigned.
+func vinAssignedText(changes []tesla.Change) string {
+	var lines []string
+	for _, change := range changes {
+		vin, ok := change.AssignedVIN()
+		if !ok {
+			continue
+		}
+		summary := vin
+		if decoded, err := tesla.DecodeVIN(vin); err == nil {
+			summary = fmt.Sprintf("%s (%s)", vin, vinSummaryText(decoded))
+		}
+		lines = append(lines, fmt.Sprintf(i18n.Text("vin_assigned_notification"), change.ReferenceNumber, summary))
+	}
+	return strings.Join(lines, "\n")
+}
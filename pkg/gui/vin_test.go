@@ -0,0 +1,68 @@
+package gui
+
+import (
+	"fmt"
+	"testing"
+
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/i18n"
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+func TestVINAssignedText(t *testing.T) {
+	changes := []tesla.Change{
+		{ReferenceNumber: "RN1", Path: "order.orderStatus", Kind: tesla.ChangeChanged, OldValue: "BOOKED", NewValue: "IN_TRANSIT"},
+		{ReferenceNumber: "RN1", Path: "order.vin", Kind: tesla.ChangeAdded, NewValue: "XP7YGCEK4SB000001"},
+	}
+
+	decoded, err := tesla.DecodeVIN("XP7YGCEK4SB000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf(i18n.Text("vin_assigned_notification"), "RN1", "XP7YGCEK4SB000001 ("+vinSummaryText(decoded)+")")
+	if got := vinAssignedText(changes); got != want {
+		t.Errorf("vinAssignedText() = %q, want %q", got, want)
+	}
+
+	if got := vinAssignedText(changes[:1]); got != "" {
+		t.Errorf("vinAssignedText() without a VIN = %q", got)
+	}
+}
+
+func TestVINSummaryText(t *testing.T) {
+	defer i18n.SetLanguage(i18n.CurrentLang)
+
+	decoded, err := tesla.DecodeVIN("XP7YGCEK5SB000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i18n.SetLanguage(i18n.LangEnglish)
+	if got, want := vinSummaryText(decoded), decoded.Summary(); got != want {
+		t.Errorf("vinSummaryText() in English = %q, want %q", got, want)
+	}
+
+	i18n.SetLanguage(i18n.LangTurkish)
+	if got, want := vinSummaryText(decoded), "2025 Model Y, üretim yeri Berlin, seri 000001, kontrol hanesi uyuşmuyor"; got != want {
+		t.Errorf("vinSummaryText() in Turkish = %q, want %q", got, want)
+	}
+}
+
+func TestAppendVINDetails(t *testing.T) {
+	form := widget.NewForm()
+	appendVINDetails(form, "XP7YGCEK4SB000001")
+
+	rows := map[string]string{}
+	for _, item := range form.Items {
+		if label, ok := item.Widget.(*widget.Label); ok {
+			rows[item.Text] = label.Text
+		}
+	}
+	if got := rows[i18n.Text("vin_model")]; got != "Model Y" {
+		t.Errorf("model row = %q", got)
+	}
+	if got := rows[i18n.Text("vin_manufacturer")]; got != "Tesla Manufacturing Brandenburg (Germany)" {
+		t.Errorf("manufacturer row = %q", got)
+	}
+}
@@ -14,6 +14,10 @@ var EnglishTexts = map[string]string{
 	"login": "Login",
 	"login_error": "Login failed: ",
 	"login_success": "Login successful",
+	"auto_capture": "Log in automatically when the browser returns",
+	"login_state_mismatch": "This redirect URL belongs to an earlier login attempt. Click Login and use the new page.",
+	"login_not_started": "No login in progress. Click Login first.",
+	"login_denied": "Tesla did not complete the login: %v",
 	"login_progress": "Logging in...",
 	"error": "Error",
 	"error_opening_browser": "Error opening browser: %v",
@@ -28,6 +32,7 @@ var EnglishTexts = map[string]string{
 	"loading_orders": "Loading Orders",
 	"fetching_orders": "Fetching Tesla orders...",
 	"error_fetching_orders": "Error fetching orders: %v",
+	"error_saving_orders": "Error saving orders and their history: %v",
 	"auto_refresh_set": "Auto refresh set to %s",
 	"logout": "Logout",
 	"order_number": "Order Number",
@@ -36,6 +41,14 @@ var EnglishTexts = map[string]string{
 	"trim": "Trim",
 	"price": "Price",
 	"status": "Status",
+	"stage": "Stage",
+	"stage_reserved": "Reserved",
+	"stage_booked": "Booked",
+	"stage_vin_assigned": "VIN Assigned",
+	"stage_in_transit": "In Transit",
+	"stage_at_delivery_center": "At Delivery Center",
+	"stage_appointment_scheduled": "Appointment Scheduled",
+	"stage_delivered": "Delivered",
 	"estimated_delivery_date": "Estimated Delivery",
 	"order_date": "Order Date",
 	"payment_type": "Payment Type",
@@ -52,12 +65,42 @@ var EnglishTexts = map[string]string{
 	"minutes": "minutes",
 	"logout_confirmation": "Logout Confirmation",
 	"logout_confirmation_message": "Are you sure you want to logout? You will need to authorize again.",
+	"all_accounts": "All Accounts",
+	"account": "Account",
+	"add_account": "Add Account",
+	"account_name": "Account Name",
+	"account_name_hint": "e.g. family",
+	"cancel": "Cancel",
+	"back": "Back",
+	"account_login_required": "Account %s needs to log in again",
+	"save": "Save",
+	"stale_details": "not refreshed",
+	"stale_details_message": "The details of this order could not be refreshed; the last saved details are shown.",
+	"market": "Market",
+	"market_country": "Country",
+	"market_language": "Language",
+	"market_auto_hint": "auto (from the order)",
 	
 	
 	"tab_summary": "Summary",
 	"tab_configuration": "Configuration",
 	"tab_pricing": "Pricing",
 	"tab_delivery": "Delivery",
+	"tab_tasks": "Tasks",
+	"task_checklist": "Delivery Tasks",
+	"no_tasks": "Tesla has not listed any tasks for this order yet.",
+	"task_complete": "Complete",
+	"task_pending": "To do",
+	"task_blocked": "Not yet available",
+	"task_registration": "Registration",
+	"task_agreements": "Agreements",
+	"task_financing": "Financing",
+	"task_tradeIn": "Trade-in",
+	"task_insurance": "Insurance",
+	"task_scheduling": "Delivery Scheduling",
+	"task_finalPayment": "Final Payment",
+	"task_deliveryDetails": "Delivery Details",
+	"task_deliveryAcceptance": "Delivery Acceptance",
 	
 	
 	"order_details": "Order Details",
@@ -66,12 +109,26 @@ var EnglishTexts = map[string]string{
 	"delivery_information": "Delivery Information",
 	"vehicle_details": "Vehicle Details",
 	"vin": "VIN",
+	"vin_model": "Model",
+	"vin_manufacturer": "Manufacturer",
+	"vin_model_year": "Model Year",
+	"vin_plant": "Factory",
+	"vin_body": "Body",
+	"vin_drivetrain": "Motor / Battery",
+	"vin_serial": "Serial Number",
+	"vin_check_digit_invalid": "The VIN check digit does not match; the VIN may have been entered or reported incorrectly.",
+	"vin_assigned_notification": "%s: VIN assigned %s",
+	"vin_summary_built_in": "built in %s",
+	"vin_summary_serial": "serial %s",
+	"vin_summary_check_digit": "check digit does not match",
 	"vehicle_odometer": "Odometer",
 	"color": "Color",
 	"interior": "Interior",
 	"wheels": "Wheels",
 	"autopilot": "Autopilot",
 	"other_options": "Other Options",
+	"option_codes": "Option Codes",
+	"no_option_codes": "Tesla did not send the option codes of this order.",
 	"pricing_details": "Pricing Details",
 	"base_price": "Base Price",
 	"options_price": "Options Price",
@@ -80,18 +137,42 @@ var EnglishTexts = map[string]string{
 	"delivery_address": "Delivery Address",
 	"delivery_method": "Delivery Method",
 	"delivery_location": "Delivery Location",
+	"unknown_store": "unknown delivery center",
+	"open_in_maps": "Open in Maps",
+	"home_location": "Home Location",
+	"home_address": "Address",
+	"home_coordinates": "Coordinates",
+	"home_coordinates_hint": "latitude, longitude",
+	"home_location_hint": "Leave the coordinates empty to remove the home location.",
+	"distance_from_home": "Distance from Home",
+	"home": "Home",
+	"approximate_location": "approximate location",
+	"approximate_distance": "approximate, to the town centre",
+	"no_store_location": "The location of this delivery center is not known.",
 	"delivery_window": "Delivery Window",
 	"estimated_arrival": "Estimated Arrival",
 	"delivery_appointment": "Delivery Appointment",
 	"reservation_date": "Reservation Date",
 	"reservation_amount": "Reservation Amount",
 	"trade_in": "Trade-in Vehicle",
+	"trade_in_credit": "Trade-in Credit",
+	"deposit": "Deposit",
+	"financed_amount": "Financed Amount",
 	"payment_details": "Payment Details",
 	"payment_status": "Payment Status",
 	"total_price": "Total Price",
 	"amount_due": "Amount Due",
 	"remaining_amount": "Remaining Amount",
+	"incentive": "Incentive",
+	"pricing_reconciled": "The price, the payments and the amount due add up.",
+	"pricing_mismatch": "The amount due does not add up: the price less payments and credits is %s, a difference of %s.",
+	"stated_total_mismatch": "Tesla's total price %s does not match the breakdown, which adds up to %s.",
+	"amount_due_changed": "changed from %s on %s",
 	"paid_amount": "Paid Amount",
+	"order_history": "Order History",
+	"no_history": "No changes recorded yet",
+	"order": "Order",
+	"order_removed": "No longer listed",
 	
 	
 	"waiting_for_final_payment": "Waiting for Final Payment",
@@ -14,6 +14,10 @@ var TurkishTexts = map[string]string{
 	"login": "Giriş Yap",
 	"login_error": "Giriş başarısız: ",
 	"login_success": "Giriş başarılı",
+	"auto_capture": "Tarayıcı geri döndüğünde otomatik giriş yap",
+	"login_state_mismatch": "Bu yönlendirme adresi önceki bir giriş denemesine ait. Giriş'e tıklayıp açılan yeni sayfayı kullanın.",
+	"login_not_started": "Devam eden bir giriş yok. Önce Giriş'e tıklayın.",
+	"login_denied": "Tesla girişi tamamlamadı: %v",
 	"login_progress": "Giriş yapılıyor...",
 	"error": "Hata",
 	"error_opening_browser": "Tarayıcı açılırken hata: %v",
@@ -28,6 +32,7 @@ var TurkishTexts = map[string]string{
 	"loading_orders": "Siparişler Yükleniyor",
 	"fetching_orders": "Tesla siparişleri alınıyor...",
 	"error_fetching_orders": "Siparişler alınırken hata: %v",
+	"error_saving_orders": "Siparişler ve geçmişleri kaydedilirken hata: %v",
 	"auto_refresh_set": "Otomatik yenileme %s olarak ayarlandı",
 	"logout": "Çıkış Yap",
 	"order_number": "Sipariş Numarası",
@@ -36,6 +41,14 @@ var TurkishTexts = map[string]string{
 	"trim": "Donanım",
 	"price": "Fiyat",
 	"status": "Durum",
+	"stage": "Aşama",
+	"stage_reserved": "Rezerve Edildi",
+	"stage_booked": "Sipariş Verildi",
+	"stage_vin_assigned": "VIN Atandı",
+	"stage_in_transit": "Yolda",
+	"stage_at_delivery_center": "Teslimat Merkezinde",
+	"stage_appointment_scheduled": "Randevu Alındı",
+	"stage_delivered": "Teslim Edildi",
 	"estimated_delivery_date": "Tahmini Teslimat",
 	"order_date": "Sipariş Tarihi",
 	"payment_type": "Ödeme Tipi",
@@ -52,12 +65,42 @@ var TurkishTexts = map[string]string{
 	"minutes": "dakika",
 	"logout_confirmation": "Çıkış Onayı",
 	"logout_confirmation_message": "Çıkış yapmak istediğinize emin misiniz? Tekrar yetkilendirme yapmanız gerekecek.",
+	"all_accounts": "Tüm Hesaplar",
+	"account": "Hesap",
+	"add_account": "Hesap Ekle",
+	"account_name": "Hesap Adı",
+	"account_name_hint": "örn. aile",
+	"cancel": "İptal",
+	"back": "Geri",
+	"save": "Kaydet",
+	"stale_details": "güncellenemedi",
+	"stale_details_message": "Bu siparişin detayları güncellenemedi; son kaydedilen detaylar gösteriliyor.",
+	"market": "Pazar",
+	"market_country": "Ülke",
+	"market_language": "Dil",
+	"market_auto_hint": "otomatik (siparişten)",
+	"account_login_required": "%s hesabı için yeniden giriş yapılması gerekiyor",
 	
 	
 	"tab_summary": "Özet",
 	"tab_configuration": "Konfigürasyon",
 	"tab_pricing": "Fiyatlandırma",
 	"tab_delivery": "Teslimat",
+	"tab_tasks": "Görevler",
+	"task_checklist": "Teslimat Görevleri",
+	"no_tasks": "Tesla bu sipariş için henüz görev listelemedi.",
+	"task_complete": "Tamamlandı",
+	"task_pending": "Yapılacak",
+	"task_blocked": "Henüz açık değil",
+	"task_registration": "Kayıt",
+	"task_agreements": "Sözleşmeler",
+	"task_financing": "Finansman",
+	"task_tradeIn": "Takas",
+	"task_insurance": "Sigorta",
+	"task_scheduling": "Teslimat Planlama",
+	"task_finalPayment": "Son Ödeme",
+	"task_deliveryDetails": "Teslimat Detayları",
+	"task_deliveryAcceptance": "Teslim Alma",
 	
 	
 	"order_details": "Sipariş Detayları",
@@ -66,12 +109,26 @@ var TurkishTexts = map[string]string{
 	"delivery_information": "Teslimat Bilgileri",
 	"vehicle_details": "Araç Detayları",
 	"vin": "VIN",
+	"vin_model": "Model",
+	"vin_manufacturer": "Üretici",
+	"vin_model_year": "Model Yılı",
+	"vin_plant": "Fabrika",
+	"vin_body": "Gövde",
+	"vin_drivetrain": "Motor / Batarya",
+	"vin_serial": "Seri Numarası",
+	"vin_check_digit_invalid": "VIN kontrol hanesi uyuşmuyor; VIN hatalı girilmiş veya bildirilmiş olabilir.",
+	"vin_assigned_notification": "%s: VIN atandı %s",
+	"vin_summary_built_in": "üretim yeri %s",
+	"vin_summary_serial": "seri %s",
+	"vin_summary_check_digit": "kontrol hanesi uyuşmuyor",
 	"vehicle_odometer": "Kilometre",
 	"color": "Renk",
 	"interior": "İç Tasarım",
 	"wheels": "Jantlar",
 	"autopilot": "Otopilot",
 	"other_options": "Diğer Seçenekler",
+	"option_codes": "Seçenek Kodları",
+	"no_option_codes": "Tesla bu siparişin seçenek kodlarını göndermedi.",
 	"pricing_details": "Fiyatlandırma Detayları",
 	"base_price": "Temel Fiyat",
 	"options_price": "Seçenekler Fiyatı",
@@ -80,18 +137,42 @@ var TurkishTexts = map[string]string{
 	"delivery_address": "Teslimat Adresi",
 	"delivery_method": "Teslimat Yöntemi",
 	"delivery_location": "Teslimat Konumu",
+	"unknown_store": "bilinmeyen teslimat merkezi",
+	"open_in_maps": "Haritada Aç",
+	"home_location": "Ev Konumu",
+	"home_address": "Adres",
+	"home_coordinates": "Koordinatlar",
+	"home_coordinates_hint": "enlem, boylam",
+	"home_location_hint": "Ev konumunu kaldırmak için koordinatları boş bırakın.",
+	"distance_from_home": "Eve Uzaklık",
+	"home": "Ev",
+	"approximate_location": "yaklaşık konum",
+	"approximate_distance": "yaklaşık, şehir merkezine",
+	"no_store_location": "Bu teslimat merkezinin konumu bilinmiyor.",
 	"delivery_window": "Teslimat Aralığı",
 	"estimated_arrival": "Tahmini Varış",
 	"delivery_appointment": "Teslimat Randevusu",
 	"reservation_date": "Rezervasyon Tarihi",
 	"reservation_amount": "Rezervasyon Tutarı",
 	"trade_in": "Takas Aracı",
+	"trade_in_credit": "Takas Bedeli",
+	"deposit": "Ön Ödeme",
+	"financed_amount": "Kredi Tutarı",
 	"payment_details": "Ödeme Detayları",
 	"payment_status": "Ödeme Durumu",
 	"total_price": "Toplam Fiyat",
 	"amount_due": "Ödenmesi Gereken",
 	"remaining_amount": "Kalan Tutar",
+	"incentive": "Teşvik",
+	"pricing_reconciled": "Fiyat, ödemeler ve kalan tutar birbiriyle uyumlu.",
+	"pricing_mismatch": "Kalan tutar uyuşmuyor: fiyattan ödemeler ve krediler düşüldüğünde %s kalıyor, fark %s.",
+	"stated_total_mismatch": "Tesla'nın bildirdiği toplam fiyat %s, döküm toplamı %s ile uyuşmuyor.",
+	"amount_due_changed": "%s tutarından değişti (%s)",
 	"paid_amount": "Ödenmiş Tutar",
+	"order_history": "Sipariş Geçmişi",
+	"no_history": "Henüz kaydedilmiş bir değişiklik yok",
+	"order": "Sipariş",
+	"order_removed": "Artık listelenmiyor",
 	
 	
 	"waiting_for_final_payment": "Son Ödeme Bekleniyor",
@@ -0,0 +1,344 @@
+// Package mockserver implements a fake Tesla auth, owner-api and tasks
+// server with scripted order fixtures, so the whole login, fetch, diff and
+// notify flow can be exercised offline.
+package mockserver
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"embed"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+//go:embed fixtures/*.json
+var fixtures embed.FS
+
+// Scenario is a scripted sequence of API states. Every request to the orders
+// endpoint advances to the next step; the last step is repeated forever.
+type Scenario struct {
+	Steps []Step `json:"steps"`
+}
+
+// Step is the state returned by the API at one point of the scenario. Tasks
+// are keyed by reference number.
+type Step struct {
+	Orders []tesla.Order                     `json:"orders"`
+	Tasks  map[string]map[string]interface{} `json:"tasks"`
+}
+
+// DefaultScenario returns the bundled scenario in which an order gets a VIN,
+// a delivery window and finally a delivery appointment.
+func DefaultScenario() Scenario {
+	scenario, err := parseScenario(mustReadFixture("fixtures/default.json"))
+	if err != nil {
+		panic(err)
+	}
+	return scenario
+}
+
+// LoadScenario reads a scenario from a JSON file.
+func LoadScenario(path string) (Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Scenario{}, err
+	}
+	return parseScenario(data)
+}
+
+func parseScenario(data []byte) (Scenario, error) {
+	var scenario Scenario
+	if err := json.Unmarshal(data, &scenario); err != nil {
+		return Scenario{}, err
+	}
+	if len(scenario.Steps) == 0 {
+		return Scenario{}, fmt.Errorf("scenario has no steps")
+	}
+	return scenario, nil
+}
+
+func mustReadFixture(name string) []byte {
+	data, err := fixtures.ReadFile(name)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// Server is an http.Handler serving the fake Tesla endpoints.
+type Server struct {
+	// TokenLifetime is the validity of the issued access tokens.
+	TokenLifetime time.Duration
+
+	mu            sync.Mutex
+	scenario      Scenario
+	current       int
+	codes         map[string]bool
+	accessTokens  map[string]time.Time
+	refreshTokens map[string]bool
+	signingKey    *rsa.PrivateKey
+	mux           *http.ServeMux
+}
+
+// signingKeyID is the key id of the id_token signing key in the JWKS.
+const signingKeyID = "mock-key"
+
+func New(scenario Scenario) *Server {
+	signingKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	s := &Server{
+		TokenLifetime: 8 * time.Hour,
+		scenario:      scenario,
+		current:       -1,
+		codes:         make(map[string]bool),
+		accessTokens:  make(map[string]time.Time),
+		refreshTokens: make(map[string]bool),
+		signingKey:    signingKey,
+		mux:           http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/oauth2/v3/authorize", s.handleAuthorize)
+	s.mux.HandleFunc("/oauth2/v3/token", s.handleToken)
+	s.mux.HandleFunc("/oauth2/v3/discovery/keys", s.handleKeys)
+	s.mux.HandleFunc("/void/callback", s.handleCallback)
+	s.mux.HandleFunc("/api/1/users/orders", s.handleOrders)
+	s.mux.HandleFunc("/tasks", s.handleTasks)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Step returns the index of the scenario step last returned by the orders
+// endpoint, or -1 before the first request.
+func (s *Server) Step() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current
+}
+
+// currentStep returns the step whose tasks are served. Callers must hold
+// s.mu.
+func (s *Server) currentStep() Step {
+	if s.current < 0 {
+		return s.scenario.Steps[0]
+	}
+	return s.scenario.Steps[s.current]
+}
+
+// handleAuthorize logs in immediately and redirects back with a code.
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI := query.Get("redirect_uri")
+	if redirectURI == "" || query.Get("code_challenge") == "" {
+		http.Error(w, "redirect_uri and code_challenge are required", http.StatusBadRequest)
+		return
+	}
+
+	code := randomToken()
+	s.mu.Lock()
+	s.codes[code] = true
+	s.mu.Unlock()
+
+	params := url.Values{}
+	params.Set("code", code)
+	params.Set("state", query.Get("state"))
+
+	separator := "?"
+	if strings.Contains(redirectURI, "?") {
+		separator = "&"
+	}
+	http.Redirect(w, r, redirectURI+separator+params.Encode(), http.StatusFound)
+}
+
+// handleCallback shows the callback URL so it can be pasted into the app.
+func (s *Server) handleCallback(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<html><body><p>Mock login complete. Copy this URL into the application:</p><pre>%s</pre></body></html>",
+		html.EscapeString(r.URL.String()))
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		code := r.PostForm.Get("code")
+		if !s.codes[code] || r.PostForm.Get("code_verifier") == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		delete(s.codes, code)
+	case "refresh_token":
+		refreshToken := r.PostForm.Get("refresh_token")
+		if !s.refreshTokens[refreshToken] {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		delete(s.refreshTokens, refreshToken)
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	expiresAt := time.Now().Add(s.TokenLifetime)
+	accessToken := unsignedJWT(map[string]interface{}{
+		"sub": "mock-user",
+		"exp": expiresAt.Unix(),
+	})
+	refreshToken := randomToken()
+
+	s.accessTokens[accessToken] = expiresAt
+	s.refreshTokens[refreshToken] = true
+
+	writeJSON(w, http.StatusOK, tesla.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		IdToken: s.signedJWT(map[string]interface{}{
+			"iss":   issuer(r),
+			"aud":   tesla.ClientID,
+			"sub":   "mock-user",
+			"email": "mock.user@example.com",
+			"name":  "Mock User",
+			"exp":   expiresAt.Unix(),
+		}),
+		ExpiresIn: int(s.TokenLifetime.Seconds()),
+		TokenType: "Bearer",
+	})
+}
+
+// handleKeys publishes the key the id_tokens are signed with.
+func (s *Server) handleKeys(w http.ResponseWriter, r *http.Request) {
+	key := s.signingKey.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": signingKeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+}
+
+func (s *Server) handleOrders(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid bearer token"})
+		return
+	}
+
+	s.mu.Lock()
+	if s.current < len(s.scenario.Steps)-1 {
+		s.current++
+	}
+	step := s.currentStep()
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"response": step.Orders})
+}
+
+func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid bearer token"})
+		return
+	}
+
+	referenceNumber := r.URL.Query().Get("referenceNumber")
+
+	s.mu.Lock()
+	tasks, ok := s.currentStep().Tasks[referenceNumber]
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown reference number"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"tasks": tasks})
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.accessTokens[token]
+	return ok && time.Now().Before(expiresAt)
+}
+
+// issuer is the id_token issuer of the server r was sent to, matching
+// tesla.EndpointsForBaseURL.
+func issuer(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + "/oauth2/v3"
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func unsignedJWT(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "none", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	return base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(randomToken()))
+}
+
+// signedJWT returns an RS256 JWT signed with the server's signing key.
+func (s *Server) signedJWT(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": signingKeyID})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.signingKey, crypto.SHA256, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func randomToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+type CommandConfig struct {
+	Enabled bool     `json:"enabled"`
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// CommandNotifier runs a shell command for every event. The event is written
+// to the command's stdin as JSON and its title and body are also passed in
+// the TESLA_EVENT_TITLE and TESLA_EVENT_BODY environment variables.
+type CommandNotifier struct {
+	config CommandConfig
+}
+
+func NewCommandNotifier(cfg CommandConfig) *CommandNotifier {
+	return &CommandNotifier{config: cfg}
+}
+
+func (n *CommandNotifier) Name() string {
+	return "command"
+}
+
+func (n *CommandNotifier) Notify(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, n.config.Command, n.config.Args...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"TESLA_EVENT_TITLE="+event.Title,
+		"TESLA_EVENT_BODY="+event.Body,
+	)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+type DesktopConfig struct {
+	Enabled bool `json:"enabled"`
+}
+
+// DesktopNotifier shows a native desktop notification using the platform's
+// command line tools, so it works without a Fyne window.
+type DesktopNotifier struct {
+	config DesktopConfig
+}
+
+func NewDesktopNotifier(cfg DesktopConfig) *DesktopNotifier {
+	return &DesktopNotifier{config: cfg}
+}
+
+func (n *DesktopNotifier) Name() string {
+	return "desktop"
+}
+
+func (n *DesktopNotifier) Notify(ctx context.Context, event Event) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(event.Body), appleScriptString(event.Title))
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	case "windows":
+		script := fmt.Sprintf(`[reflection.assembly]::loadwithpartialname('System.Windows.Forms') | Out-Null;`+
+			`$n = New-Object System.Windows.Forms.NotifyIcon;`+
+			`$n.Icon = [System.Drawing.SystemIcons]::Information;`+
+			`$n.Visible = $true;`+
+			`$n.ShowBalloonTip(10000, %s, %s, 'Info');`+
+			`Start-Sleep -Seconds 10; $n.Dispose()`, powerShellString(event.Title), powerShellString(event.Body))
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", script)
+	case "linux", "freebsd", "openbsd", "netbsd":
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return fmt.Errorf("notify-send not found")
+		}
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=Tesla Order Tracker", event.Title, event.Body)
+	default:
+		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
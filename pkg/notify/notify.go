@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+// Event describes a set of order changes to be delivered to the user.
+type Event struct {
+	Time    time.Time      `json:"time"`
+	Account string         `json:"account,omitempty"`
+	Title   string         `json:"title"`
+	Body    string         `json:"body"`
+	Changes []tesla.Change `json:"changes"`
+}
+
+// Notifier delivers events to a single backend.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, event Event) error
+}
+
+// Config selects and configures the notifier backends.
+type Config struct {
+	Desktop DesktopConfig `json:"desktop"`
+	Webhook WebhookConfig `json:"webhook"`
+	Email   EmailConfig   `json:"email"`
+	Command CommandConfig `json:"command"`
+}
+
+// NewEvent builds an event summarizing the given changes, with the decoded
+// vehicle below a newly assigned VIN. The account name is added to the title
+// when it is not empty.
+func NewEvent(account string, changes []tesla.Change) Event {
+	title := "Tesla order changes detected"
+	if len(changes) == 1 {
+		title = "Tesla order change detected"
+	}
+	if account != "" {
+		title = fmt.Sprintf("%s (%s)", title, account)
+	}
+
+	lines := make([]string, 0, len(changes))
+	for _, change := range changes {
+		lines = append(lines, change.String())
+		if vin, ok := change.AssignedVIN(); ok {
+			if decoded, err := tesla.DecodeVIN(vin); err == nil {
+				lines = append(lines, "  "+decoded.Summary())
+			}
+		}
+	}
+
+	return Event{
+		Time:    time.Now(),
+		Account: account,
+		Title:   title,
+		Body:    strings.Join(lines, "\n"),
+		Changes: changes,
+	}
+}
+
+// FromConfig returns the notifiers enabled in cfg.
+func FromConfig(cfg Config) ([]Notifier, error) {
+	notifiers := []Notifier{}
+
+	if cfg.Desktop.Enabled {
+		notifiers = append(notifiers, NewDesktopNotifier(cfg.Desktop))
+	}
+
+	if cfg.Webhook.Enabled {
+		if cfg.Webhook.URL == "" {
+			return nil, fmt.Errorf("webhook notifier: url is required")
+		}
+		notifiers = append(notifiers, NewWebhookNotifier(cfg.Webhook))
+	}
+
+	if cfg.Email.Enabled {
+		if cfg.Email.Host == "" || cfg.Email.From == "" || len(cfg.Email.To) == 0 {
+			return nil, fmt.Errorf("email notifier: host, from and to are required")
+		}
+		notifiers = append(notifiers, NewEmailNotifier(cfg.Email))
+	}
+
+	if cfg.Command.Enabled {
+		if cfg.Command.Command == "" {
+			return nil, fmt.Errorf("command notifier: command is required")
+		}
+		notifiers = append(notifiers, NewCommandNotifier(cfg.Command))
+	}
+
+	return notifiers, nil
+}
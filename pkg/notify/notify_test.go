@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+func TestNewEvent(t *testing.T) {
+	changes := []tesla.Change{
+		{ReferenceNumber: "RN1", Path: "order.orderStatus", Kind: tesla.ChangeChanged, OldValue: "BOOKED", NewValue: "DELIVERED"},
+	}
+
+	event := NewEvent("", changes)
+	if event.Title != "Tesla order change detected" {
+		t.Errorf("Title = %q", event.Title)
+	}
+	if want := changes[0].String(); event.Body != want {
+		t.Errorf("Body = %q, want %q", event.Body, want)
+	}
+
+	changes = append(changes, tesla.Change{ReferenceNumber: "RN2", Kind: tesla.ChangeAdded})
+	event = NewEvent("Personal", changes)
+	if event.Title != "Tesla order changes detected (Personal)" || event.Account != "Personal" {
+		t.Errorf("Title = %q, Account = %q", event.Title, event.Account)
+	}
+	if want := changes[0].String() + "\n" + changes[1].String(); event.Body != want {
+		t.Errorf("Body = %q, want %q", event.Body, want)
+	}
+}
+
+func TestNewEventAssignedVIN(t *testing.T) {
+	changes := []tesla.Change{
+		{ReferenceNumber: "RN1", Path: "order.vin", Kind: tesla.ChangeChanged, OldValue: "", NewValue: "XP7YGCEK4SB000001"},
+		{ReferenceNumber: "RN2", Path: "order.vin", Kind: tesla.ChangeChanged, OldValue: "XP7YGCEK4SB000001", NewValue: "XP7YGCEK4SB000002"},
+	}
+	decoded, err := tesla.DecodeVIN("XP7YGCEK4SB000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the first VIN of an order is described.
+	event := NewEvent("", changes)
+	want := changes[0].String() + "\n  " + decoded.Summary() + "\n" + changes[1].String()
+	if event.Body != want {
+		t.Errorf("Body = %q, want %q", event.Body, want)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	notifiers, err := FromConfig(Config{
+		Desktop: DesktopConfig{Enabled: true},
+		Webhook: WebhookConfig{Enabled: true, URL: "http://localhost/hook"},
+		Command: CommandConfig{Enabled: false},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, n := range notifiers {
+		names = append(names, n.Name())
+	}
+	if len(names) != 2 || names[0] != "desktop" || names[1] != "webhook" {
+		t.Errorf("FromConfig() = %v, want [desktop webhook]", names)
+	}
+}
+
+func TestFromConfigIncomplete(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"webhook": {Webhook: WebhookConfig{Enabled: true}},
+		"email":   {Email: EmailConfig{Enabled: true, Host: "smtp.example.com"}},
+		"command": {Command: CommandConfig{Enabled: true}},
+	} {
+		if _, err := FromConfig(cfg); err == nil {
+			t.Errorf("FromConfig() accepted an incomplete %s notifier", name)
+		}
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "secret" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	event := NewEvent("", []tesla.Change{{ReferenceNumber: "RN1", Kind: tesla.ChangeAdded}})
+	notifier := NewWebhookNotifier(WebhookConfig{URL: server.URL, Headers: map[string]string{"X-Token": "secret"}})
+	if err := notifier.Notify(t.Context(), event); err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != event.Title || got.Body != event.Body || len(got.Changes) != 1 {
+		t.Errorf("webhook received %+v, want %+v", got, event)
+	}
+
+	notifier = NewWebhookNotifier(WebhookConfig{URL: server.URL})
+	notifier.client.SetRetryCount(0)
+	if err := notifier.Notify(t.Context(), event); err == nil {
+		t.Error("Notify() succeeded on 403")
+	}
+}
@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+type WebhookConfig struct {
+	Enabled bool              `json:"enabled"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// WebhookNotifier posts the event as JSON to a URL.
+type WebhookNotifier struct {
+	config WebhookConfig
+	client *resty.Client
+}
+
+func NewWebhookNotifier(cfg WebhookConfig) *WebhookNotifier {
+	client := resty.New()
+	client.SetTimeout(30 * time.Second)
+	client.SetRetryCount(2)
+	client.SetRetryWaitTime(5 * time.Second)
+
+	return &WebhookNotifier{
+		config: cfg,
+		client: client,
+	}
+}
+
+func (n *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, event Event) error {
+	resp, err := n.client.R().
+		SetContext(ctx).
+		SetHeaders(n.config.Headers).
+		SetHeader("Content-Type", "application/json").
+		SetBody(event).
+		Post(n.config.URL)
+	if err != nil {
+		return err
+	}
+
+	if resp.IsError() {
+		return fmt.Errorf("webhook returned %s: %s", resp.Status(), resp.String())
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package tesla
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// DefaultAccountName is the account that keeps using the token and order
+// files directly in ConfigDir, so single-account installations keep their
+// data.
+const DefaultAccountName = "default"
+
+var accountNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,31}$`)
+
+// Account is a named profile with its own tokens, order snapshot and history.
+type Account struct {
+	Name string `json:"name"`
+
+	// Market is the country and language of the account's orders. Its zero
+	// value detects them from the orders.
+	Market Market `json:"market,omitzero"`
+}
+
+// Dir returns the directory holding the account's files.
+func (a Account) Dir() string {
+	if a.Name == DefaultAccountName {
+		return ConfigDir
+	}
+	return filepath.Join(ConfigDir, "accounts", a.Name)
+}
+
+func (a Account) TokenFile() string {
+	return filepath.Join(a.Dir(), "tesla_tokens.json")
+}
+
+func (a Account) ProfileFile() string {
+	return filepath.Join(a.Dir(), "tesla_profile.json")
+}
+
+// Profile returns the saved profile of the account, or the zero Profile if
+// it never logged in or the profile cannot be read.
+func (a Account) Profile() Profile {
+	profile, err := LoadProfile(a.ProfileFile())
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	return profile
+}
+
+func (a Account) OrdersFile() string {
+	return filepath.Join(a.Dir(), "tesla_orders.json")
+}
+
+func (a Account) HistoryFile() string {
+	return filepath.Join(a.Dir(), "tesla_history.jsonl")
+}
+
+// NewTeslaAuth returns a TeslaAuth reading and writing the account's tokens.
+func (a Account) NewTeslaAuth() *TeslaAuth {
+	auth := NewTeslaAuth()
+	auth.TokenFile = a.TokenFile()
+	auth.ProfileFile = a.ProfileFile()
+	return auth
+}
+
+// NewOrderManager returns an OrderManager using the account's order snapshot
+// and history.
+func (a Account) NewOrderManager(auth *TeslaAuth) *OrderManager {
+	manager := NewOrderManager(auth)
+	manager.OrdersFile = a.OrdersFile()
+	manager.HistoryFile = a.HistoryFile()
+	manager.Market = a.Market
+	return manager
+}
+
+// AccountStore is the list of configured accounts, saved in AccountsFile.
+type AccountStore struct {
+	Accounts []Account `json:"accounts"`
+	Active   string    `json:"active"`
+}
+
+// AccountsFile returns the location of the account list.
+func AccountsFile() string {
+	return filepath.Join(ConfigDir, "accounts.json")
+}
+
+// LoadAccounts reads the account list. Without a saved list there is a
+// single default account.
+func LoadAccounts() (*AccountStore, error) {
+	store := &AccountStore{
+		Accounts: []Account{{Name: DefaultAccountName}},
+		Active:   DefaultAccountName,
+	}
+
+	data, err := os.ReadFile(AccountsFile())
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return store, err
+	}
+
+	if err := json.Unmarshal(data, store); err != nil {
+		return store, fmt.Errorf("invalid account list %s: %w", AccountsFile(), err)
+	}
+
+	if len(store.Accounts) == 0 {
+		store.Accounts = []Account{{Name: DefaultAccountName}}
+	}
+	if _, ok := store.Get(store.Active); !ok {
+		store.Active = store.Accounts[0].Name
+	}
+
+	return store, nil
+}
+
+func (s *AccountStore) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(AccountsFile(), data, 0600)
+}
+
+func (s *AccountStore) Get(name string) (Account, bool) {
+	for _, account := range s.Accounts {
+		if account.Name == name {
+			return account, true
+		}
+	}
+	return Account{}, false
+}
+
+// ActiveAccount returns the account selected last.
+func (s *AccountStore) ActiveAccount() Account {
+	if account, ok := s.Get(s.Active); ok {
+		return account
+	}
+	return s.Accounts[0]
+}
+
+// Add creates a new account and its directory.
+func (s *AccountStore) Add(name string) (Account, error) {
+	if !accountNamePattern.MatchString(name) {
+		return Account{}, fmt.Errorf("invalid account name %q: use up to 32 letters, digits, '.', '_' or '-'", name)
+	}
+	if _, ok := s.Get(name); ok {
+		return Account{}, fmt.Errorf("account %q already exists", name)
+	}
+
+	account := Account{Name: name}
+	if err := os.MkdirAll(account.Dir(), 0700); err != nil {
+		return Account{}, err
+	}
+
+	s.Accounts = append(s.Accounts, account)
+	return account, s.Save()
+}
+
+// Remove deletes an account together with its tokens, profile, orders and
+// history.
+func (s *AccountStore) Remove(name string) error {
+	if len(s.Accounts) == 1 {
+		return fmt.Errorf("cannot remove the only account")
+	}
+
+	for i, account := range s.Accounts {
+		if account.Name != name {
+			continue
+		}
+
+		if err := NewTokenStore(account.TokenFile()).Delete(); err != nil {
+			return err
+		}
+		for _, file := range []string{account.ProfileFile(), account.OrdersFile(), account.HistoryFile()} {
+			if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if name != DefaultAccountName {
+			os.Remove(account.Dir())
+		}
+
+		s.Accounts = append(s.Accounts[:i], s.Accounts[i+1:]...)
+		if s.Active == name {
+			s.Active = s.Accounts[0].Name
+		}
+		return s.Save()
+	}
+
+	return fmt.Errorf("account %q not found", name)
+}
+
+// SetMarket changes the market of an account. The zero Market switches back
+// to detecting it from the orders.
+func (s *AccountStore) SetMarket(name string, market Market) error {
+	for i := range s.Accounts {
+		if s.Accounts[i].Name == name {
+			s.Accounts[i].Market = market
+			return s.Save()
+		}
+	}
+	return fmt.Errorf("account %q not found", name)
+}
+
+// SetActive selects the account used by default.
+func (s *AccountStore) SetActive(name string) error {
+	if _, ok := s.Get(name); !ok {
+		return fmt.Errorf("account %q not found", name)
+	}
+
+	s.Active = name
+	return s.Save()
+}
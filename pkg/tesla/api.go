@@ -1,10 +1,12 @@
 package tesla
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -12,6 +14,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"crypto/tls"
@@ -27,23 +30,36 @@ const (
 	Scope                 = "openid email offline_access"
 	CodeChallengeMethod   = "S256"
 	AppVersion            = "4.43.0-3212"
+	
+	// TokenRefreshLeeway is how long before the access token expires that it is
+	// proactively refreshed.
+	TokenRefreshLeeway    = 5 * time.Minute
 )
 
+// ErrLoginRequired is returned when the stored tokens can no longer be used
+// and the user has to go through the interactive login again.
+var ErrLoginRequired = errors.New("login required")
+
 var (
-	TokenFile  string
-	OrdersFile string
+	ConfigDir   string
+	TokenFile   string
+	ProfileFile string
+	OrdersFile  string
+	HistoryFile string
 )
 
 func init() {
-	configDir := getConfigDir()
+	ConfigDir = getConfigDir()
 	
-	err := os.MkdirAll(configDir, 0700)
+	err := os.MkdirAll(ConfigDir, 0700)
 	if err != nil {
 		fmt.Printf("Warning: Could not create config directory: %v\n", err)
 	}
 	
-	TokenFile = filepath.Join(configDir, "tesla_tokens.json")
-	OrdersFile = filepath.Join(configDir, "tesla_orders.json")
+	TokenFile = filepath.Join(ConfigDir, "tesla_tokens.json")
+	ProfileFile = filepath.Join(ConfigDir, "tesla_profile.json")
+	OrdersFile = filepath.Join(ConfigDir, "tesla_orders.json")
+	HistoryFile = filepath.Join(ConfigDir, "tesla_history.jsonl")
 }
 
 func getConfigDir() string {
@@ -67,7 +83,21 @@ type TeslaAuth struct {
 	CodeChallenge string
 	AccessToken   string
 	RefreshToken  string
+	TokenFile     string
+	TokenStore    TokenStore
+	// ProfileFile receives the account profile from the id_token of each
+	// login and refresh.
+	ProfileFile   string
+	// State is the OAuth state of the pending login, set by GetAuthURL and
+	// checked by ParseCallback.
+	State         string
+	Endpoints     Endpoints
 	Client        *resty.Client
+	
+	refreshMu     sync.Mutex
+	// storeMu guards the lazy default of TokenStore, which the concurrent
+	// fetchers of several orders can reach at the same time.
+	storeMu       sync.Mutex
 }
 
 type TokenResponse struct {
@@ -104,58 +134,85 @@ func NewTeslaAuth() *TeslaAuth {
 		return err != nil || r.StatusCode() >= 500
 	})
 	
+	endpoints, err := LoadEndpoints()
+	if err != nil {
+		fmt.Printf("Warning: Could not load endpoint overrides: %v\n", err)
+	}
+	
 	auth := &TeslaAuth{
-		Client: client,
+		TokenFile:   TokenFile,
+		ProfileFile: ProfileFile,
+		Endpoints:   endpoints,
+		Client:      client,
 	}
 
-	auth.GenerateCodeVerifierAndChallenge()
-
 	return auth
 }
 
-func (a *TeslaAuth) GenerateCodeVerifierAndChallenge() {
+// GenerateCodeVerifierAndChallenge creates a new PKCE verifier and its
+// challenge.
+func (a *TeslaAuth) GenerateCodeVerifierAndChallenge() error {
 	b := make([]byte, 32)
-	rand.Read(b)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("generating code verifier: %w", err)
+	}
 	a.CodeVerifier = base64.URLEncoding.WithPadding(base64.NoPadding).EncodeToString(b)
 
 	h := sha256.New()
 	h.Write([]byte(a.CodeVerifier))
 	a.CodeChallenge = base64.URLEncoding.WithPadding(base64.NoPadding).EncodeToString(h.Sum(nil))
+	return nil
 }
 
-func (a *TeslaAuth) GetAuthURL() string {
+// GetAuthURL starts a login attempt with a fresh PKCE verifier and state and
+// returns the URL to open in the browser. A callback of an earlier attempt
+// is rejected afterwards.
+func (a *TeslaAuth) GetAuthURL() (string, error) {
+	if err := a.GenerateCodeVerifierAndChallenge(); err != nil {
+		return "", err
+	}
+	
 	state := make([]byte, 16)
-	rand.Read(state)
-	stateStr := fmt.Sprintf("%x", state)
+	if _, err := rand.Read(state); err != nil {
+		return "", fmt.Errorf("generating state: %w", err)
+	}
+	a.State = fmt.Sprintf("%x", state)
 	
 	params := url.Values{}
 	params.Add("client_id", ClientID)
-	params.Add("redirect_uri", RedirectURI)
+	params.Add("redirect_uri", a.Endpoints.RedirectURI)
 	params.Add("response_type", "code")
 	params.Add("scope", Scope)
-	params.Add("state", stateStr)
+	params.Add("state", a.State)
 	params.Add("code_challenge", a.CodeChallenge)
 	params.Add("code_challenge_method", CodeChallengeMethod)
 	
-	return fmt.Sprintf("%s?%s", AuthURL, params.Encode())
+	return fmt.Sprintf("%s?%s", a.Endpoints.AuthURL, params.Encode()), nil
 }
 
-func (a *TeslaAuth) ExchangeCodeForTokens(authCode string) error {
+// ExchangeCodeForTokens completes the pending login with the authorization
+// code of its callback.
+func (a *TeslaAuth) ExchangeCodeForTokens(ctx context.Context, authCode string) error {
+	if a.CodeVerifier == "" {
+		return ErrNoPendingLogin
+	}
+	
 	formData := map[string]string{
 		"grant_type":    "authorization_code",
 		"client_id":     ClientID,
 		"code":          authCode,
-		"redirect_uri":  RedirectURI,
+		"redirect_uri":  a.Endpoints.RedirectURI,
 		"code_verifier": a.CodeVerifier,
 	}
 	
 	resp, err := a.Client.R().
+		SetContext(ctx).
 		EnableTrace().
 		SetFormData(formData).
 		SetHeader("Content-Type", "application/x-www-form-urlencoded").
 		SetHeader("Accept", "application/json").
 		SetHeader("User-Agent", "TeslaGoClient/1.0").
-		Post(TokenURL)
+		Post(a.Endpoints.TokenURL)
 	
 	if err != nil {
 		return err
@@ -172,92 +229,199 @@ func (a *TeslaAuth) ExchangeCodeForTokens(authCode string) error {
 	
 	a.AccessToken = tokenResp.AccessToken
 	a.RefreshToken = tokenResp.RefreshToken
+	a.updateProfile(ctx, tokenResp.IdToken)
+	
+	// The verifier and state are single use.
+	a.CodeVerifier = ""
+	a.CodeChallenge = ""
+	a.State = ""
 	
 	return nil
 }
 
-func (a *TeslaAuth) SaveTokensToFile() error {
-	data := map[string]string{
-		"access_token":  a.AccessToken,
-		"refresh_token": a.RefreshToken,
-	}
-	
-	fileData, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
+// SaveTokens stores the tokens in the account's token store.
+func (a *TeslaAuth) SaveTokens() error {
+	return a.tokenStore().Save(Tokens{
+		AccessToken:  a.AccessToken,
+		RefreshToken: a.RefreshToken,
+	})
+}
+
+// DeleteTokens forgets the tokens and removes them from the token store.
+func (a *TeslaAuth) DeleteTokens() error {
+	a.AccessToken = ""
+	a.RefreshToken = ""
 	
-	return os.WriteFile(TokenFile, fileData, 0600)
+	return a.tokenStore().Delete()
 }
 
-func (a *TeslaAuth) LoadTokensFromFile() error {
-	fileData, err := os.ReadFile(TokenFile)
+// LoadTokens reads the tokens from the token store. It returns ErrNoTokens
+// when none are saved.
+func (a *TeslaAuth) LoadTokens() error {
+	tokens, err := a.tokenStore().Load()
 	if err != nil {
 		return err
 	}
 	
-	var data map[string]string
-	if err := json.Unmarshal(fileData, &data); err != nil {
-		return err
-	}
-	
-	a.AccessToken = data["access_token"]
-	a.RefreshToken = data["refresh_token"]
+	a.AccessToken = tokens.AccessToken
+	a.RefreshToken = tokens.RefreshToken
 	
 	return nil
 }
 
+// tokenStore returns TokenStore, or the default store for TokenFile. The
+// default is made on first use since TokenFile may be set after
+// NewTeslaAuth.
+func (a *TeslaAuth) tokenStore() TokenStore {
+	a.storeMu.Lock()
+	defer a.storeMu.Unlock()
+	
+	if a.TokenStore == nil {
+		a.TokenStore = NewTokenStore(a.TokenFile)
+	}
+	return a.TokenStore
+}
+
 func (a *TeslaAuth) IsTokenValid() bool {
-	if a.AccessToken == "" {
+	expiry, err := a.TokenExpiry()
+	if err != nil {
 		return false
 	}
 	
-	parts := strings.Split(a.AccessToken, ".")
-	if len(parts) != 3 {
-		return false
+	return expiry.After(time.Now())
+}
+
+// TokenExpiry returns the expiry time encoded in the access token's exp claim.
+func (a *TeslaAuth) TokenExpiry() (time.Time, error) {
+	if a.AccessToken == "" {
+		return time.Time{}, errors.New("no access token")
 	}
 	
-	payload := parts[1]
-	if len(payload)%4 != 0 {
-		payload += strings.Repeat("=", 4-len(payload)%4)
+	parts := strings.Split(a.AccessToken, ".")
+	if len(parts) != 3 {
+		return time.Time{}, errors.New("access token is not a JWT")
 	}
 	
-	decodedBytes, err := base64.StdEncoding.DecodeString(payload)
+	// JWT segments are unpadded base64url, which may contain '-' and '_'.
+	decodedBytes, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
 	if err != nil {
-		return false
+		return time.Time{}, err
 	}
 	
 	var claims Claim
 	if err := json.Unmarshal(decodedBytes, &claims); err != nil {
-		return false
+		return time.Time{}, err
+	}
+	
+	return time.Unix(claims.Exp, 0), nil
+}
+
+// NeedsRefresh reports whether the access token is missing, unreadable or
+// expires within TokenRefreshLeeway.
+func (a *TeslaAuth) NeedsRefresh() bool {
+	expiry, err := a.TokenExpiry()
+	if err != nil {
+		return true
 	}
 	
-	return claims.Exp > time.Now().Unix()
+	return time.Until(expiry) < TokenRefreshLeeway
+}
+
+// EnsureValidToken refreshes the access token if it is about to expire. It
+// returns an error wrapping ErrLoginRequired when there is no usable refresh
+// token.
+func (a *TeslaAuth) EnsureValidToken(ctx context.Context) error {
+	a.refreshMu.Lock()
+	if !a.NeedsRefresh() {
+		a.refreshMu.Unlock()
+		return nil
+	}
+	
+	idToken, err := a.refreshTokens(ctx)
+	a.refreshMu.Unlock()
+	
+	a.updateProfile(ctx, idToken)
+	return err
+}
+
+// RefreshAfterUnauthorized refreshes the tokens after a request made with
+// staleToken was rejected. If another caller already rotated the token in the
+// meantime, the new token is kept and no further refresh is made.
+func (a *TeslaAuth) RefreshAfterUnauthorized(ctx context.Context, staleToken string) error {
+	a.refreshMu.Lock()
+	if a.AccessToken != staleToken {
+		a.refreshMu.Unlock()
+		return nil
+	}
+	
+	idToken, err := a.refreshTokens(ctx)
+	a.refreshMu.Unlock()
+	
+	a.updateProfile(ctx, idToken)
+	return err
 }
 
-func (a *TeslaAuth) RefreshTokens() error {
+func (a *TeslaAuth) RefreshTokens(ctx context.Context) error {
+	a.refreshMu.Lock()
+	idToken, err := a.refreshTokens(ctx)
+	a.refreshMu.Unlock()
+	
+	a.updateProfile(ctx, idToken)
+	return err
+}
+
+// currentAccessToken returns the access token, waiting for a refresh in
+// progress to finish.
+func (a *TeslaAuth) currentAccessToken() string {
+	a.refreshMu.Lock()
+	defer a.refreshMu.Unlock()
+	
+	return a.AccessToken
+}
+
+// refreshTokens exchanges the refresh token for new tokens and saves them.
+// It returns the id_token for the caller to pass to updateProfile once
+// refreshMu is released, as verifying it may have to fetch the signing keys.
+func (a *TeslaAuth) refreshTokens(ctx context.Context) (string, error) {
+	if a.RefreshToken == "" {
+		return "", fmt.Errorf("%w: no refresh token", ErrLoginRequired)
+	}
+	
 	resp, err := a.Client.R().
+		SetContext(ctx).
 		SetFormData(map[string]string{
 			"grant_type":    "refresh_token",
 			"client_id":     ClientID,
 			"refresh_token": a.RefreshToken,
 		}).
-		Post(TokenURL)
+		SetHeader("Accept", "application/json").
+		Post(a.Endpoints.TokenURL)
 	
 	if err != nil {
-		return err
+		return "", err
 	}
 	
-	if resp.StatusCode() != 200 {
-		return fmt.Errorf("failed to refresh tokens: %s", resp.String())
+	switch resp.StatusCode() {
+	case http.StatusOK:
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden:
+		return "", fmt.Errorf("%w: refresh token rejected: %s", ErrLoginRequired, resp.String())
+	default:
+		return "", fmt.Errorf("failed to refresh tokens: %s", resp.String())
 	}
 	
 	var tokenResp TokenResponse
 	if err := json.Unmarshal(resp.Body(), &tokenResp); err != nil {
-		return err
+		return "", err
+	}
+	
+	if tokenResp.AccessToken == "" {
+		return "", fmt.Errorf("failed to refresh tokens: empty access token in response")
 	}
 	
 	a.AccessToken = tokenResp.AccessToken
+	if tokenResp.RefreshToken != "" {
+		a.RefreshToken = tokenResp.RefreshToken
+	}
 	
-	return nil
-} 
\ No newline at end of file
+	return tokenResp.IdToken, a.SaveTokens()
+}
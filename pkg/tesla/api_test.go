@@ -0,0 +1,104 @@
+package tesla
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testJWT returns an unsigned JWT carrying claims.
+func testJWT(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	return header + "." + base64.RawURLEncoding.EncodeToString(payload) + ".signature"
+}
+
+func TestIsTokenValid(t *testing.T) {
+	future := time.Now().Add(time.Hour).Unix()
+	past := time.Now().Add(-time.Hour).Unix()
+
+	// This payload encodes to base64url containing '_', so it only decodes
+	// with the URL alphabet.
+	urlAlphabet := testJWT(t, map[string]interface{}{"exp": int64(4102444800), "x": "??"})
+	if payload := strings.Split(urlAlphabet, ".")[1]; !strings.ContainsAny(payload, "-_") {
+		t.Fatalf("payload %q does not use the URL alphabet", payload)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  bool
+	}{
+		{"empty", "", false},
+		{"not a jwt", "abc.def", false},
+		{"invalid base64", "a.!!!.c", false},
+		{"invalid json", "a." + base64.RawURLEncoding.EncodeToString([]byte("nope")) + ".c", false},
+		{"expired", testJWT(t, map[string]interface{}{"exp": past}), false},
+		{"valid", testJWT(t, map[string]interface{}{"exp": future}), true},
+		{"valid needing padding", testJWT(t, map[string]interface{}{"exp": future, "a": "b"}), true},
+		{"valid with url alphabet", urlAlphabet, true},
+		{"valid with explicit padding", urlAlphabet[:len(urlAlphabet)-len(".signature")] + "==.signature", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &TeslaAuth{AccessToken: tt.token}
+			if got := auth.IsTokenValid(); got != tt.want {
+				t.Errorf("IsTokenValid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsRefresh(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn time.Duration
+		want      bool
+	}{
+		{"far from expiry", time.Hour, false},
+		{"within leeway", TokenRefreshLeeway / 2, true},
+		{"expired", -time.Minute, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &TeslaAuth{AccessToken: testJWT(t, map[string]interface{}{"exp": time.Now().Add(tt.expiresIn).Unix()})}
+			if got := auth.NeedsRefresh(); got != tt.want {
+				t.Errorf("NeedsRefresh() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenStoreDefaultIsShared(t *testing.T) {
+	t.Setenv(TokenStoreEnv, "file")
+	auth := &TeslaAuth{TokenFile: filepath.Join(t.TempDir(), "tokens.json")}
+
+	stores := make([]TokenStore, 8)
+	var wg sync.WaitGroup
+	for i := range stores {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stores[i] = auth.tokenStore()
+		}()
+	}
+	wg.Wait()
+
+	for _, store := range stores {
+		if store != auth.TokenStore {
+			t.Fatalf("tokenStore() returned %p and %p", store, auth.TokenStore)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package tesla
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Errors returned for a callback URL that cannot complete the login.
+var (
+	// ErrNoPendingLogin means GetAuthURL was not called, or its login was
+	// already completed.
+	ErrNoPendingLogin = errors.New("no login in progress, start the login again")
+	// ErrStateMismatch is returned for a callback that does not belong to
+	// the pending login, such as one left over from an earlier attempt.
+	ErrStateMismatch = errors.New("callback state does not match the pending login")
+	// ErrNoAuthCode is returned for a URL without an authorization code.
+	ErrNoAuthCode = errors.New("callback URL has no 'code' parameter")
+)
+
+// CallbackError is the error Tesla redirected back with, for example when
+// the login was cancelled.
+type CallbackError struct {
+	Code        string
+	Description string
+}
+
+func (e *CallbackError) Error() string {
+	if e.Description == "" {
+		return "login failed: " + e.Code
+	}
+	return fmt.Sprintf("login failed: %s (%s)", e.Code, e.Description)
+}
+
+// ParseCallback returns the authorization code from the callback URL the
+// browser was redirected to after login, checking that it belongs to the
+// pending login.
+func (a *TeslaAuth) ParseCallback(callbackURL string) (string, error) {
+	callbackURL = strings.TrimSpace(callbackURL)
+	if !strings.Contains(callbackURL, "://") {
+		callbackURL = "https://" + callbackURL
+	}
+
+	parsedURL, err := url.Parse(callbackURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid callback URL: %w", err)
+	}
+	return checkCallback(parsedURL.Query(), a.State)
+}
+
+// checkCallback checks the state before anything else, so an error that
+// does not belong to the pending login cannot abort it.
+func checkCallback(query url.Values, state string) (string, error) {
+	if state == "" {
+		return "", ErrNoPendingLogin
+	}
+	if query.Get("state") != state {
+		return "", ErrStateMismatch
+	}
+	if code := query.Get("error"); code != "" {
+		return "", &CallbackError{Code: code, Description: query.Get("error_description")}
+	}
+	if query.Get("code") == "" {
+		return "", ErrNoAuthCode
+	}
+	return query.Get("code"), nil
+}
+
+// IsLoopbackRedirect reports whether redirectURI points at this machine, in
+// which case the callback can be received by ListenForCallback instead of
+// being pasted by the user.
+func IsLoopbackRedirect(redirectURI string) bool {
+	u, err := url.Parse(redirectURI)
+	if err != nil || u.Scheme != "http" {
+		return false
+	}
+	if u.Hostname() == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(u.Hostname())
+	return ip != nil && ip.IsLoopback()
+}
+
+// MatchCallback returns the authorization code if s is the callback URL of
+// the pending login: a URL under the redirect URI with a code and the state
+// sent by GetAuthURL. It is meant for scanning text such as the clipboard,
+// so anything else is silently rejected.
+func (a *TeslaAuth) MatchCallback(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, a.Endpoints.RedirectURI) {
+		return "", false
+	}
+
+	code, err := a.ParseCallback(s)
+	return code, err == nil
+}
+
+// CallbackServer receives the browser's redirect after login on the
+// loopback address of the redirect URI.
+type CallbackServer struct {
+	server  *http.Server
+	results chan callbackResult
+}
+
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// ListenForCallback starts listening on the host and port of the redirect
+// URI, which must be a loopback address. Call it before opening the auth
+// URL, and Close the server once the login is over.
+func (a *TeslaAuth) ListenForCallback() (*CallbackServer, error) {
+	if !IsLoopbackRedirect(a.Endpoints.RedirectURI) {
+		return nil, fmt.Errorf("redirect URI %s is not a loopback address", a.Endpoints.RedirectURI)
+	}
+	redirect, err := url.Parse(a.Endpoints.RedirectURI)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", redirect.Host)
+	if err != nil {
+		return nil, fmt.Errorf("listening for the login callback: %w", err)
+	}
+
+	s := &CallbackServer{results: make(chan callbackResult, 1)}
+	path := redirect.Path
+	if path == "" {
+		path = "/"
+	}
+	state := a.State
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		code, err := checkCallback(r.URL.Query(), state)
+		switch {
+		case errors.Is(err, ErrStateMismatch):
+			// Not ours; keep waiting for the real callback.
+			writeCallbackPage(w, http.StatusBadRequest, err.Error())
+		case err != nil:
+			writeCallbackPage(w, http.StatusBadRequest, err.Error())
+			s.report(callbackResult{err: err})
+		default:
+			writeCallbackPage(w, http.StatusOK, "Login complete. You can close this window and return to the application.")
+			s.report(callbackResult{code: code})
+		}
+	})
+
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go s.server.Serve(listener)
+
+	return s, nil
+}
+
+// report passes the first result to Wait and drops any later ones.
+func (s *CallbackServer) report(result callbackResult) {
+	select {
+	case s.results <- result:
+	default:
+	}
+}
+
+// Wait returns the authorization code of the first valid callback.
+func (s *CallbackServer) Wait(ctx context.Context) (string, error) {
+	select {
+	case result := <-s.results:
+		return result.code, result.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Close stops listening.
+func (s *CallbackServer) Close() error {
+	return s.server.Close()
+}
+
+func writeCallbackPage(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<html><body><p>%s</p></body></html>", html.EscapeString(message))
+}
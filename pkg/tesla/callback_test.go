@@ -0,0 +1,215 @@
+package tesla
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestIsLoopbackRedirect(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want bool
+	}{
+		{"http://127.0.0.1:8788/callback", true},
+		{"http://localhost:8788/callback", true},
+		{"http://[::1]:8788/callback", true},
+		{"https://127.0.0.1:8788/callback", false},
+		{"https://auth.tesla.com/void/callback", false},
+		{"http://192.168.1.2/callback", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsLoopbackRedirect(tt.uri); got != tt.want {
+			t.Errorf("IsLoopbackRedirect(%q) = %v, want %v", tt.uri, got, tt.want)
+		}
+	}
+}
+
+func TestParseCallback(t *testing.T) {
+	a := &TeslaAuth{Endpoints: DefaultEndpoints()}
+	if _, err := a.ParseCallback(RedirectURI + "?code=abc123&state=xyz"); !errors.Is(err, ErrNoPendingLogin) {
+		t.Errorf("ParseCallback() before GetAuthURL error = %v, want ErrNoPendingLogin", err)
+	}
+
+	if _, err := a.GetAuthURL(); err != nil {
+		t.Fatal(err)
+	}
+	state := a.State
+
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr error
+	}{
+		{"full url", "https://auth.tesla.com/void/callback?code=abc123&state=" + state, "abc123", nil},
+		{"code last", "https://auth.tesla.com/void/callback?state=" + state + "&code=abc123", "abc123", nil},
+		{"without scheme", "auth.tesla.com/void/callback?code=abc123&state=" + state, "abc123", nil},
+		{"surrounding whitespace", "  https://auth.tesla.com/void/callback?code=abc123&state=" + state + "\n", "abc123", nil},
+		{"escaped code", "https://auth.tesla.com/void/callback?code=a%2Bb&state=" + state, "a+b", nil},
+		{"earlier attempt", "https://auth.tesla.com/void/callback?code=abc123&state=xyz", "", ErrStateMismatch},
+		{"missing state", "https://auth.tesla.com/void/callback?code=abc123", "", ErrStateMismatch},
+		{"missing code", "https://auth.tesla.com/void/callback?state=" + state, "", ErrNoAuthCode},
+		{"empty code", "https://auth.tesla.com/void/callback?code=&state=" + state, "", ErrNoAuthCode},
+		{"empty", "", "", ErrStateMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.ParseCallback(tt.url)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseCallback() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseCallback() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	var callbackErr *CallbackError
+	if _, err := a.ParseCallback(RedirectURI + "?error=login_cancelled&state=" + state); !errors.As(err, &callbackErr) {
+		t.Errorf("ParseCallback() error = %v, want a CallbackError", err)
+	}
+	if _, err := a.ParseCallback(RedirectURI + "?error=login_cancelled"); !errors.Is(err, ErrStateMismatch) {
+		t.Errorf("ParseCallback() of an error without state = %v, want ErrStateMismatch", err)
+	}
+}
+
+func TestGetAuthURLStartsNewAttempt(t *testing.T) {
+	a := &TeslaAuth{Endpoints: DefaultEndpoints()}
+	if _, err := a.GetAuthURL(); err != nil {
+		t.Fatal(err)
+	}
+	verifier, state := a.CodeVerifier, a.State
+
+	authURL, err := a.GetAuthURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.CodeVerifier == verifier || a.State == state {
+		t.Error("GetAuthURL() reused the verifier or state of the previous attempt")
+	}
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.Query().Get("state"); got != a.State {
+		t.Errorf("auth URL state = %q, want %q", got, a.State)
+	}
+	if got := u.Query().Get("code_challenge"); got != a.CodeChallenge {
+		t.Errorf("auth URL code_challenge = %q, want %q", got, a.CodeChallenge)
+	}
+}
+
+func TestMatchCallback(t *testing.T) {
+	a := &TeslaAuth{Endpoints: DefaultEndpoints()}
+	if _, err := a.GetAuthURL(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		text   string
+		want   string
+		wantOK bool
+	}{
+		{"callback", RedirectURI + "?code=abc&state=" + a.State, "abc", true},
+		{"surrounding space", " " + RedirectURI + "?state=" + a.State + "&code=abc\n", "abc", true},
+		{"other state", RedirectURI + "?code=abc&state=old", "", false},
+		{"no code", RedirectURI + "?state=" + a.State, "", false},
+		{"other site", "https://example.com/?code=abc&state=" + a.State, "", false},
+		{"text", "hello", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := a.MatchCallback(tt.text)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("MatchCallback() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestListenForCallback(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	a := &TeslaAuth{Endpoints: Endpoints{AuthURL: AuthURL, RedirectURI: "http://" + addr + "/callback"}}
+	if _, err := a.GetAuthURL(); err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := a.ListenForCallback()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	callback := func(params url.Values) int {
+		resp, err := http.Get(a.Endpoints.RedirectURI + "?" + params.Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := callback(url.Values{"code": {"stale"}, "state": {"old"}}); status != http.StatusBadRequest {
+		t.Errorf("callback with another state: status %d, want %d", status, http.StatusBadRequest)
+	}
+	if status := callback(url.Values{"error": {"access_denied"}}); status != http.StatusBadRequest {
+		t.Errorf("error callback without a state: status %d, want %d", status, http.StatusBadRequest)
+	}
+	if status := callback(url.Values{"code": {"abc"}, "state": {a.State}}); status != http.StatusOK {
+		t.Errorf("callback: status %d, want %d", status, http.StatusOK)
+	}
+
+	code, err := server.Wait(t.Context())
+	if err != nil || code != "abc" {
+		t.Errorf("Wait() = %q, %v, want %q", code, err, "abc")
+	}
+}
+
+func TestListenForCallbackError(t *testing.T) {
+	a := &TeslaAuth{Endpoints: Endpoints{RedirectURI: RedirectURI}}
+	if _, err := a.ListenForCallback(); err == nil {
+		t.Error("ListenForCallback() accepted a non-loopback redirect URI")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	a.Endpoints.RedirectURI = "http://" + addr + "/callback"
+	if _, err := a.GetAuthURL(); err != nil {
+		t.Fatal(err)
+	}
+	server, err := a.ListenForCallback()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	resp, err := http.Get(a.Endpoints.RedirectURI + "?error=access_denied&state=" + a.State)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	var callbackErr *CallbackError
+	if _, err := server.Wait(t.Context()); !errors.As(err, &callbackErr) || callbackErr.Code != "access_denied" {
+		t.Errorf("Wait() error = %v, want the access_denied CallbackError", err)
+	}
+}
@@ -0,0 +1,82 @@
+package tesla
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+)
+
+// Change is a single difference between two snapshots of an order. Path is
+// the dotted JSON path inside DetailedOrder, e.g. "order.orderStatus"; it is
+// empty when the whole order was added or removed.
+type Change struct {
+	ReferenceNumber string      `json:"referenceNumber"`
+	Path            string      `json:"path,omitempty"`
+	Kind            ChangeKind  `json:"kind"`
+	OldValue        interface{} `json:"oldValue,omitempty"`
+	NewValue        interface{} `json:"newValue,omitempty"`
+}
+
+// fieldPaths maps the JSON paths of the fields returned by ExtractOrderInfo
+// to their info keys.
+var fieldPaths = map[string]string{
+	"order.modelCode":   "Model",
+	"order.orderStatus": "Status",
+	"order.vin":         "VIN",
+	"order.mktOptions":  "Options",
+	"details.tasks.registration.orderDetails.reservationDate":        "ReservationDate",
+	"details.tasks.registration.orderDetails.orderBookedDate":        "OrderBookedDate",
+	"details.tasks.registration.orderDetails.vehicleOdometer":        "VehicleOdometer",
+	"details.tasks.registration.orderDetails.vehicleOdometerType":    "VehicleOdometer",
+	"details.tasks.registration.orderDetails.vehicleRoutingLocation": "VehicleRoutingLocation",
+	"details.tasks.scheduling.deliveryWindowDisplay":                 "DeliveryWindow",
+	"details.tasks.scheduling.apptDateTimeAddressStr":                "DeliveryAppointment",
+	"details.tasks.finalPayment.data.etaToDeliveryCenter":            "ETAToDeliveryCenter",
+	"details.tasks.finalPayment.amountDue":                           "AmountDue",
+	"details.tasks.finalPayment.data.amountDue":                      "AmountDue",
+}
+
+// Fields returns the ExtractOrderInfo keys affected by the change. A change
+// of a parent object, such as a task appearing, affects every field below it.
+func (c Change) Fields() []string {
+	if c.Path == "" {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	fields := []string{}
+	for path, field := range fieldPaths {
+		if (path == c.Path || strings.HasPrefix(path, c.Path+".")) && !seen[field] {
+			seen[field] = true
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func (c Change) String() string {
+	if c.Path == "" {
+		switch c.Kind {
+		case ChangeAdded:
+			return fmt.Sprintf("Added order %s", c.ReferenceNumber)
+		case ChangeRemoved:
+			return fmt.Sprintf("Removed order %s", c.ReferenceNumber)
+		}
+	}
+
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("%s: added '%s': %s", c.ReferenceNumber, c.Path, formatValue(c.NewValue))
+	case ChangeRemoved:
+		return fmt.Sprintf("%s: removed '%s'", c.ReferenceNumber, c.Path)
+	default:
+		return fmt.Sprintf("%s: changed '%s': %s -> %s", c.ReferenceNumber, c.Path, formatValue(c.OldValue), formatValue(c.NewValue))
+	}
+}
@@ -0,0 +1,97 @@
+package tesla
+
+import (
+	"slices"
+	"sort"
+)
+
+// TaskProgress is where a task stands in the checklist.
+type TaskProgress string
+
+const (
+	TaskComplete TaskProgress = "complete"
+	TaskPending  TaskProgress = "pending"
+	// TaskBlocked is a task Tesla has not enabled yet, usually because an
+	// earlier one is not complete.
+	TaskBlocked TaskProgress = "blocked"
+)
+
+// ChecklistTasks are the task keys in the order they are done before
+// delivery. Tasks Tesla adds that are not listed here follow them.
+var ChecklistTasks = []string{
+	"registration",
+	"agreements",
+	"financing",
+	"tradeIn",
+	"insurance",
+	"scheduling",
+	"finalPayment",
+	"deliveryDetails",
+	"deliveryAcceptance",
+}
+
+// ChecklistItem is one task of the order with its progress.
+type ChecklistItem struct {
+	Key      string
+	Progress TaskProgress
+	// Status is Tesla's own status code of the task, if any.
+	Status string
+	Card   *TaskCard
+}
+
+// Checklist lists every task of the payload, in ChecklistTasks order. An
+// entry is taken for a task when it is an object reporting "complete".
+func (d OrderDetails) Checklist() []ChecklistItem {
+	var items []ChecklistItem
+	for key, value := range d.Tasks {
+		raw, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := raw["complete"].(bool); !ok {
+			continue
+		}
+
+		var state *TaskState
+		decodeTask(d.Tasks, key, &state)
+		if state == nil {
+			continue
+		}
+
+		item := ChecklistItem{Key: key, Progress: TaskPending, Status: state.Status, Card: state.Card}
+		_, reportsEnabled := raw["enabled"]
+		switch {
+		case state.Complete:
+			item.Progress = TaskComplete
+		case reportsEnabled && !state.Enabled:
+			item.Progress = TaskBlocked
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		a, b := checklistRank(items[i].Key), checklistRank(items[j].Key)
+		if a != b {
+			return a < b
+		}
+		return items[i].Key < items[j].Key
+	})
+	return items
+}
+
+func checklistRank(key string) int {
+	if i := slices.Index(ChecklistTasks, key); i >= 0 {
+		return i
+	}
+	return len(ChecklistTasks)
+}
+
+// ChecklistCounts returns how many of the items are complete.
+func ChecklistCounts(items []ChecklistItem) (complete, total int) {
+	for _, item := range items {
+		if item.Progress == TaskComplete {
+			complete++
+		}
+	}
+	return complete, len(items)
+}
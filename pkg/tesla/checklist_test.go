@@ -0,0 +1,86 @@
+package tesla
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChecklist(t *testing.T) {
+	details := OrderDetails{Tasks: map[string]interface{}{
+		"finalPayment": map[string]interface{}{"complete": false, "enabled": false},
+		"registration": map[string]interface{}{"complete": true, "enabled": true},
+		"scheduling": map[string]interface{}{
+			"complete": false,
+			"enabled":  true,
+			"status":   "SELECT_APPOINTMENT",
+			"card": map[string]interface{}{
+				"title":        "Schedule Delivery",
+				"messageTitle": "Schedule Delivery",
+				"messageBody":  "Choose a delivery appointment.",
+			},
+		},
+		"wallCharger": map[string]interface{}{"complete": false},
+		"strings":     map[string]interface{}{"title": "not a task"},
+		"version":     "2",
+	}}
+
+	items := details.Checklist()
+
+	var keys []string
+	for _, item := range items {
+		keys = append(keys, item.Key)
+	}
+	if want := []string{"registration", "scheduling", "finalPayment", "wallCharger"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("Checklist() keys = %v, want %v", keys, want)
+	}
+
+	want := []TaskProgress{TaskComplete, TaskPending, TaskBlocked, TaskPending}
+	for i, item := range items {
+		if item.Progress != want[i] {
+			t.Errorf("%s progress = %s, want %s", item.Key, item.Progress, want[i])
+		}
+	}
+
+	scheduling := items[1]
+	if scheduling.Status != "SELECT_APPOINTMENT" {
+		t.Errorf("scheduling status = %q", scheduling.Status)
+	}
+	if got := scheduling.Card.Text(); got != "Schedule Delivery\nChoose a delivery appointment." {
+		t.Errorf("card text = %q", got)
+	}
+	if got := items[0].Card.Text(); got != "" {
+		t.Errorf("card text without card = %q", got)
+	}
+
+	if complete, total := ChecklistCounts(items); complete != 1 || total != 4 {
+		t.Errorf("ChecklistCounts() = %d/%d, want 1/4", complete, total)
+	}
+}
+
+func TestChecklistKeepsTaskWithBadCard(t *testing.T) {
+	details := OrderDetails{Tasks: map[string]interface{}{
+		"finalPayment": map[string]interface{}{"complete": false, "enabled": true, "card": "Pay now", "amountDue": 1500.0},
+		"scheduling":   map[string]interface{}{"complete": false, "enabled": true, "card": 42.0},
+	}}
+
+	items := details.Checklist()
+	if len(items) != 2 {
+		t.Fatalf("Checklist() = %+v, want both tasks", items)
+	}
+	for _, item := range items {
+		switch item.Key {
+		case "finalPayment":
+			if got := item.Card.Text(); got != "Pay now" {
+				t.Errorf("finalPayment card = %q, want the string card as its title", got)
+			}
+		case "scheduling":
+			if got := item.Card.Text(); got != "" {
+				t.Errorf("scheduling card = %q, want none", got)
+			}
+		}
+	}
+
+	if finalPayment := details.TypedTasks().FinalPayment; finalPayment == nil || finalPayment.AmountDue.Value != 1500 {
+		t.Errorf("FinalPayment = %+v, want the task with its amount due", finalPayment)
+	}
+}
@@ -0,0 +1,315 @@
+package tesla
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testServer fakes the orders, tasks and token endpoints. Requests are only
+// authorized with validToken; refresh grants are answered by refresh.
+type testServer struct {
+	validToken string
+	orders     http.HandlerFunc
+	tasks      http.HandlerFunc
+	refresh    http.HandlerFunc
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/oauth2/v3/token":
+		if s.refresh == nil {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		s.refresh(w, r)
+		return
+	case "/api/1/users/orders", "/tasks":
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+s.validToken {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.URL.Path == "/tasks" {
+		s.tasks(w, r)
+	} else {
+		s.orders(w, r)
+	}
+}
+
+// newTestManager returns an OrderManager talking to s, logged in with a
+// fresh access token.
+func newTestManager(t *testing.T, s *testServer) *OrderManager {
+	t.Helper()
+
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+
+	auth := NewTeslaAuth()
+	auth.Endpoints = EndpointsForBaseURL(server.URL)
+	auth.TokenFile = filepath.Join(t.TempDir(), "tokens.json")
+	auth.TokenStore = &PlainFileStore{Path: auth.TokenFile}
+	auth.ProfileFile = filepath.Join(t.TempDir(), "profile.json")
+	auth.AccessToken = testJWT(t, map[string]interface{}{"exp": time.Now().Add(time.Hour).Unix()})
+	auth.RefreshToken = "refresh-1"
+	auth.Client.SetRetryWaitTime(time.Millisecond).SetRetryMaxWaitTime(time.Millisecond)
+
+	if s.validToken == "" {
+		s.validToken = auth.AccessToken
+	}
+
+	return NewOrderManager(auth)
+}
+
+// serveFixture answers with a recorded response from testdata.
+func serveFixture(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join("testdata", name))
+	}
+}
+
+var writeOrders = serveFixture("orders.json")
+
+func TestRetrieveOrders(t *testing.T) {
+	m := newTestManager(t, &testServer{orders: writeOrders})
+
+	orders, err := m.RetrieveOrders(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Order{ReferenceNumber: "RN100000001", OrderStatus: "BOOKED", ModelCode: "my", VIN: "XP7YGCEK4SB000001"}
+	if len(orders) != 1 || orders[0] != want {
+		t.Errorf("RetrieveOrders() = %+v, want [%+v]", orders, want)
+	}
+}
+
+func TestRetrieveOrdersError(t *testing.T) {
+	m := newTestManager(t, &testServer{orders: func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}})
+
+	if _, err := m.RetrieveOrders(t.Context()); err == nil {
+		t.Fatal("RetrieveOrders() succeeded on 403")
+	}
+}
+
+func TestRetrieveOrdersRetriesServerErrors(t *testing.T) {
+	var calls int32
+	m := newTestManager(t, &testServer{orders: func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		writeOrders(w, r)
+	}})
+
+	orders, err := m.RetrieveOrders(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 1 {
+		t.Errorf("RetrieveOrders() returned %d orders, want 1", len(orders))
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("orders endpoint called %d times, want 3", got)
+	}
+}
+
+func TestRetrieveOrdersRefreshesOnUnauthorized(t *testing.T) {
+	s := &testServer{orders: writeOrders, validToken: "rotated-access"}
+	s.refresh = func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("refresh_token") != "refresh-1" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"access_token":"rotated-access","refresh_token":"refresh-2"}`)
+	}
+	m := newTestManager(t, s)
+
+	if _, err := m.RetrieveOrders(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := &TeslaAuth{TokenStore: m.Auth.TokenStore}
+	if err := saved.LoadTokens(); err != nil {
+		t.Fatal(err)
+	}
+	if saved.AccessToken != "rotated-access" || saved.RefreshToken != "refresh-2" {
+		t.Errorf("saved tokens = %q, %q, want rotated tokens", saved.AccessToken, saved.RefreshToken)
+	}
+}
+
+func TestRetrieveOrdersRefreshRejected(t *testing.T) {
+	m := newTestManager(t, &testServer{orders: writeOrders, validToken: "other"})
+
+	_, err := m.RetrieveOrders(t.Context())
+	if !errors.Is(err, ErrLoginRequired) {
+		t.Fatalf("RetrieveOrders() error = %v, want ErrLoginRequired", err)
+	}
+}
+
+func TestGetOrderDetails(t *testing.T) {
+	m := newTestManager(t, &testServer{tasks: func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("referenceNumber") != "RN100000001" {
+			http.Error(w, "unknown order", http.StatusNotFound)
+			return
+		}
+		if query.Get("deviceCountry") != "TR" || query.Get("deviceLanguage") != "tr" {
+			http.Error(w, "unexpected market "+query.Encode(), http.StatusBadRequest)
+			return
+		}
+		serveFixture("tasks.json")(w, r)
+	}})
+
+	details, err := m.GetOrderDetails(t.Context(), "RN100000001", Market{Country: "TR", Language: "tr"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := m.ExtractOrderInfo(DetailedOrder{Details: *details})
+	want := map[string]string{
+		"ReservationDate":        "2025-01-10T09:12:45.000Z",
+		"VehicleOdometer":        "15",
+		"VehicleRoutingLocation": "2614.00",
+		"DeliveryWindow":         "Mar 1 - Mar 15",
+		"ETAToDeliveryCenter":    "Mar 3",
+	}
+	for key, value := range want {
+		if info[key] != value {
+			t.Errorf("info[%q] = %q, want %q", key, info[key], value)
+		}
+	}
+	if _, ok := info["DeliveryAppointment"]; ok {
+		t.Errorf("null appointment was set to %q", info["DeliveryAppointment"])
+	}
+
+	if _, err := m.GetOrderDetails(t.Context(), "RN2", Market{}); err == nil {
+		t.Error("GetOrderDetails() succeeded for an unknown order")
+	}
+}
+
+func TestGetDetailedOrdersPartialFailure(t *testing.T) {
+	m := newTestManager(t, &testServer{
+		orders: func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"response":[{"referenceNumber":"RN1"},{"referenceNumber":"RN2"},{"referenceNumber":"RN3"}]}`)
+		},
+		tasks: func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("referenceNumber") == "RN1" {
+				fmt.Fprint(w, `{"tasks":{"registration":{"complete":true}}}`)
+				return
+			}
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		},
+	})
+	m.OrdersFile = filepath.Join(t.TempDir(), "orders.json")
+	m.HistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+
+	saved := []DetailedOrder{{
+		Order:   Order{ReferenceNumber: "RN2"},
+		Details: OrderDetails{Tasks: map[string]interface{}{"scheduling": map[string]interface{}{"complete": false}}},
+	}}
+	if err := m.SaveOrdersToFile(saved); err != nil {
+		t.Fatal(err)
+	}
+
+	orders, err := m.GetDetailedOrders(t.Context())
+
+	var orderErr *OrderError
+	if !errors.As(err, &orderErr) {
+		t.Fatalf("GetDetailedOrders() error = %v, want *OrderError", err)
+	}
+	if len(orders) != 3 {
+		t.Fatalf("GetDetailedOrders() returned %d orders, want 3", len(orders))
+	}
+
+	if orders[0].Stale || orders[0].Details.Tasks["registration"] == nil {
+		t.Errorf("RN1 = %+v, want fresh details", orders[0])
+	}
+	if !orders[1].Stale || orders[1].Details.Tasks["scheduling"] == nil {
+		t.Errorf("RN2 = %+v, want stale saved details", orders[1])
+	}
+	if !orders[2].Stale || orders[2].Details.Tasks != nil {
+		t.Errorf("RN3 = %+v, want stale without details", orders[2])
+	}
+
+	if changes := m.CompareOrders(saved, orders[1:2]); len(changes) != 0 {
+		t.Errorf("CompareOrders() reported changes of stale details: %v", changes)
+	}
+}
+
+func TestGetDetailedOrdersCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+
+	m := newTestManager(t, &testServer{
+		orders: func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"response":[{"referenceNumber":"RN1"},{"referenceNumber":"RN2"}]}`)
+		},
+		tasks: func(w http.ResponseWriter, r *http.Request) {
+			cancel()
+			<-r.Context().Done()
+		},
+	})
+	m.OrdersFile = filepath.Join(t.TempDir(), "orders.json")
+
+	start := time.Now()
+	orders, err := m.GetDetailedOrders(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetDetailedOrders() error = %v, want context.Canceled", err)
+	}
+	if orders != nil {
+		t.Errorf("GetDetailedOrders() returned %d orders after cancellation", len(orders))
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("GetDetailedOrders() took %v after cancellation", elapsed)
+	}
+}
+
+func TestExchangeCodeForTokens(t *testing.T) {
+	var verifier string
+	m := newTestManager(t, &testServer{
+		refresh: func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseForm(); err != nil || r.PostForm.Get("code") != "abc" {
+				http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+				return
+			}
+			verifier = r.PostForm.Get("code_verifier")
+			fmt.Fprint(w, `{"access_token":"new-access","refresh_token":"new-refresh"}`)
+		},
+	})
+	auth := m.Auth
+
+	if err := auth.ExchangeCodeForTokens(t.Context(), "abc"); !errors.Is(err, ErrNoPendingLogin) {
+		t.Fatalf("ExchangeCodeForTokens() without a login error = %v, want ErrNoPendingLogin", err)
+	}
+
+	if _, err := auth.GetAuthURL(); err != nil {
+		t.Fatal(err)
+	}
+	want := auth.CodeVerifier
+
+	if err := auth.ExchangeCodeForTokens(t.Context(), "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if verifier != want {
+		t.Errorf("code_verifier = %q, want %q", verifier, want)
+	}
+	if auth.AccessToken != "new-access" || auth.RefreshToken != "new-refresh" {
+		t.Errorf("tokens = %q, %q", auth.AccessToken, auth.RefreshToken)
+	}
+	if auth.CodeVerifier != "" || auth.State != "" {
+		t.Error("the verifier and state of the completed login were kept")
+	}
+}
@@ -0,0 +1,121 @@
+package tesla
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	JWKSURL   = "https://auth.tesla.com/oauth2/v3/discovery/keys"
+	OrdersURL = "https://owner-api.teslamotors.com/api/1/users/orders"
+	TasksURL  = "https://akamai-apigateway-vfx.tesla.com/tasks"
+)
+
+// Endpoints are the URLs of the Tesla services used by the application. They
+// can be overridden to go through a proxy or to use a local mock server.
+type Endpoints struct {
+	AuthURL     string `json:"authUrl,omitempty"`
+	TokenURL    string `json:"tokenUrl,omitempty"`
+	RedirectURI string `json:"redirectUri,omitempty"`
+	JWKSURL     string `json:"jwksUrl,omitempty"`
+	OrdersURL   string `json:"ordersUrl,omitempty"`
+	TasksURL    string `json:"tasksUrl,omitempty"`
+}
+
+func DefaultEndpoints() Endpoints {
+	return Endpoints{
+		AuthURL:     AuthURL,
+		TokenURL:    TokenURL,
+		RedirectURI: RedirectURI,
+		JWKSURL:     JWKSURL,
+		OrdersURL:   OrdersURL,
+		TasksURL:    TasksURL,
+	}
+}
+
+// Issuer returns the issuer the id_tokens of AuthURL carry: the OAuth base
+// URL without the "/authorize" path.
+func (e Endpoints) Issuer() string {
+	return strings.TrimSuffix(e.AuthURL, "/authorize")
+}
+
+// EndpointsForBaseURL returns the endpoints of a server implementing all
+// services under one base URL, such as the bundled mock server.
+func EndpointsForBaseURL(baseURL string) Endpoints {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return Endpoints{
+		AuthURL:     baseURL + "/oauth2/v3/authorize",
+		TokenURL:    baseURL + "/oauth2/v3/token",
+		RedirectURI: baseURL + "/void/callback",
+		JWKSURL:     baseURL + "/oauth2/v3/discovery/keys",
+		OrdersURL:   baseURL + "/api/1/users/orders",
+		TasksURL:    baseURL + "/tasks",
+	}
+}
+
+// EndpointsFile returns the location of the optional endpoint overrides.
+func EndpointsFile() string {
+	return filepath.Join(ConfigDir, "endpoints.json")
+}
+
+// LoadEndpoints returns the default endpoints with the overrides from
+// EndpointsFile and then from the environment applied. TESLA_API_BASE_URL
+// points every endpoint at one server; TESLA_AUTH_URL, TESLA_TOKEN_URL,
+// TESLA_REDIRECT_URI, TESLA_JWKS_URL, TESLA_ORDERS_URL and TESLA_TASKS_URL
+// override single endpoints.
+func LoadEndpoints() (Endpoints, error) {
+	endpoints := DefaultEndpoints()
+
+	data, err := os.ReadFile(EndpointsFile())
+	if err != nil && !os.IsNotExist(err) {
+		return endpoints, err
+	}
+	if err == nil {
+		var overrides Endpoints
+		if err := json.Unmarshal(data, &overrides); err != nil {
+			return endpoints, fmt.Errorf("invalid endpoints file %s: %w", EndpointsFile(), err)
+		}
+		endpoints = endpoints.merge(overrides)
+	}
+
+	if baseURL := os.Getenv("TESLA_API_BASE_URL"); baseURL != "" {
+		endpoints = EndpointsForBaseURL(baseURL)
+	}
+
+	endpoints = endpoints.merge(Endpoints{
+		AuthURL:     os.Getenv("TESLA_AUTH_URL"),
+		TokenURL:    os.Getenv("TESLA_TOKEN_URL"),
+		RedirectURI: os.Getenv("TESLA_REDIRECT_URI"),
+		JWKSURL:     os.Getenv("TESLA_JWKS_URL"),
+		OrdersURL:   os.Getenv("TESLA_ORDERS_URL"),
+		TasksURL:    os.Getenv("TESLA_TASKS_URL"),
+	})
+
+	return endpoints, nil
+}
+
+// merge returns e with every non-empty field of overrides applied.
+func (e Endpoints) merge(overrides Endpoints) Endpoints {
+	if overrides.AuthURL != "" {
+		e.AuthURL = overrides.AuthURL
+	}
+	if overrides.TokenURL != "" {
+		e.TokenURL = overrides.TokenURL
+	}
+	if overrides.RedirectURI != "" {
+		e.RedirectURI = overrides.RedirectURI
+	}
+	if overrides.JWKSURL != "" {
+		e.JWKSURL = overrides.JWKSURL
+	}
+	if overrides.OrdersURL != "" {
+		e.OrdersURL = overrides.OrdersURL
+	}
+	if overrides.TasksURL != "" {
+		e.TasksURL = overrides.TasksURL
+	}
+	return e
+}
@@ -0,0 +1,264 @@
+package tesla
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// TimelineFields are the ExtractOrderInfo keys whose changes are recorded in
+// the order history.
+var TimelineFields = []string{"Status", "VIN", "DeliveryWindow", "ETAToDeliveryCenter", "DeliveryAppointment", "AmountDue"}
+
+// OrderField is the HistoryEntry field recording that an order is no longer
+// returned by the API. The old value is its last status and the new value
+// OrderRemoved.
+const OrderField = "Order"
+
+// OrderRemoved is the new value of an OrderField entry.
+const OrderRemoved = "REMOVED"
+
+// HistoryEntry records a change of one timeline field. Entries are appended
+// to the manager's HistoryFile as JSON lines and never rewritten.
+type HistoryEntry struct {
+	Time            time.Time `json:"time"`
+	ReferenceNumber string    `json:"referenceNumber"`
+	Field           string    `json:"field"`
+	OldValue        string    `json:"oldValue,omitempty"`
+	NewValue        string    `json:"newValue,omitempty"`
+}
+
+// HistoryEntries returns the timeline field changes between two snapshots,
+// and the lifecycle stage transitions under StageField. Orders seen for the
+// first time get an entry for every field that already has a value; orders
+// missing from new get an OrderField entry.
+func (m *OrderManager) HistoryEntries(old, new []DetailedOrder, at time.Time) []HistoryEntry {
+	oldInfos := make(map[string]map[string]string, len(old))
+	oldOrders := make(map[string]DetailedOrder, len(old))
+	for _, order := range old {
+		oldInfos[order.Order.ReferenceNumber] = m.ExtractOrderInfo(order)
+		oldOrders[order.Order.ReferenceNumber] = order
+	}
+
+	// Stages are compared with the last recorded one rather than with the
+	// stage of the old snapshot: a stage can change with time alone, such as
+	// when the ETA passes, and orders recorded before stages were get one.
+	stages, stagesErr := m.recordedStages()
+	if stagesErr != nil {
+		fmt.Printf("Warning: Could not read the recorded stages: %v\n", stagesErr)
+	}
+
+	entries := []HistoryEntry{}
+	present := make(map[string]bool, len(new))
+	for _, order := range new {
+		referenceNumber := order.Order.ReferenceNumber
+		present[referenceNumber] = true
+		oldInfo := oldInfos[referenceNumber]
+		newInfo := m.ExtractOrderInfo(order)
+
+		for _, field := range TimelineFields {
+			oldValue := timelineValue(oldInfo[field])
+			newValue := timelineValue(newInfo[field])
+			if oldValue == newValue {
+				continue
+			}
+
+			entries = append(entries, HistoryEntry{
+				Time:            at,
+				ReferenceNumber: referenceNumber,
+				Field:           field,
+				OldValue:        oldValue,
+				NewValue:        newValue,
+			})
+		}
+
+		oldStage, recorded := stages[referenceNumber]
+		if !recorded && stagesErr != nil {
+			if previous, ok := oldOrders[referenceNumber]; ok {
+				oldStage = OrderStage(previous, at)
+			}
+		}
+		if newStage := OrderStage(order, at); newStage != oldStage {
+			entries = append(entries, HistoryEntry{
+				Time:            at,
+				ReferenceNumber: referenceNumber,
+				Field:           StageField,
+				OldValue:        string(oldStage),
+				NewValue:        string(newStage),
+			})
+		}
+	}
+
+	for _, order := range old {
+		referenceNumber := order.Order.ReferenceNumber
+		if present[referenceNumber] {
+			continue
+		}
+		present[referenceNumber] = true
+
+		entries = append(entries, HistoryEntry{
+			Time:            at,
+			ReferenceNumber: referenceNumber,
+			Field:           OrderField,
+			OldValue:        timelineValue(oldInfos[referenceNumber]["Status"]),
+			NewValue:        OrderRemoved,
+		})
+	}
+
+	return entries
+}
+
+// stageRecord is what the history recorded about the stages of one order.
+type stageRecord struct {
+	last  Stage
+	times map[Stage]time.Time
+}
+
+// loadStages reads the recorded stages from the history file the first time
+// they are needed; AppendHistory keeps them up to date afterwards. Callers
+// must hold m.stagesMu.
+func (m *OrderManager) loadStages() error {
+	if m.stages != nil {
+		return nil
+	}
+
+	entries, err := m.LoadHistory("")
+	if err != nil {
+		return err
+	}
+	m.stages = make(map[string]*stageRecord)
+	m.recordStages(entries)
+	return nil
+}
+
+// recordStages applies the StageField entries to the loaded stages. Callers
+// must hold m.stagesMu.
+func (m *OrderManager) recordStages(entries []HistoryEntry) {
+	for _, entry := range entries {
+		if entry.Field != StageField {
+			continue
+		}
+		record := m.stages[entry.ReferenceNumber]
+		if record == nil {
+			record = &stageRecord{times: make(map[Stage]time.Time)}
+			m.stages[entry.ReferenceNumber] = record
+		}
+		stage := Stage(entry.NewValue)
+		record.last = stage
+		if _, seen := record.times[stage]; !seen {
+			record.times[stage] = entry.Time
+		}
+	}
+}
+
+// recordedStages returns the last recorded stage of every order.
+func (m *OrderManager) recordedStages() (map[string]Stage, error) {
+	m.stagesMu.Lock()
+	defer m.stagesMu.Unlock()
+
+	if err := m.loadStages(); err != nil {
+		return nil, err
+	}
+	stages := make(map[string]Stage, len(m.stages))
+	for referenceNumber, record := range m.stages {
+		stages[referenceNumber] = record.last
+	}
+	return stages, nil
+}
+
+// RecordedStageTimes returns when the order first reached each stage, as
+// StageTimes does for its history entries, without reading the history file
+// again once it has been read.
+func (m *OrderManager) RecordedStageTimes(referenceNumber string) (map[Stage]time.Time, error) {
+	m.stagesMu.Lock()
+	defer m.stagesMu.Unlock()
+
+	if err := m.loadStages(); err != nil {
+		return nil, err
+	}
+	times := make(map[Stage]time.Time)
+	if record := m.stages[referenceNumber]; record != nil {
+		for stage, at := range record.times {
+			times[stage] = at
+		}
+	}
+	return times, nil
+}
+
+func timelineValue(value string) string {
+	if value == "N/A" {
+		return ""
+	}
+	return value
+}
+
+// AppendHistory appends entries to the history file and to the recorded
+// stages.
+func (m *OrderManager) AppendHistory(entries []HistoryEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	err := m.appendHistory(entries)
+
+	m.stagesMu.Lock()
+	defer m.stagesMu.Unlock()
+	if err != nil {
+		// Some of the entries may have been written; read them back next
+		// time.
+		m.stages = nil
+	} else if m.stages != nil {
+		m.recordStages(entries)
+	}
+	return err
+}
+
+func (m *OrderManager) appendHistory(entries []HistoryEntry) error {
+
+	file, err := os.OpenFile(m.HistoryFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+
+	return file.Sync()
+}
+
+// LoadHistory returns the recorded entries of an order in chronological
+// order, or of all orders if referenceNumber is empty.
+func (m *OrderManager) LoadHistory(referenceNumber string) ([]HistoryEntry, error) {
+	file, err := os.Open(m.HistoryFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := []HistoryEntry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// A partially written last line must not make the whole history
+			// unreadable.
+			continue
+		}
+		if referenceNumber == "" || entry.ReferenceNumber == referenceNumber {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, scanner.Err()
+}
@@ -0,0 +1,47 @@
+package tesla
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistoryEntriesRemovedOrder(t *testing.T) {
+	m := &OrderManager{}
+	at := time.Now()
+
+	kept := testOrder("RN1", "BOOKED", nil)
+	removed := testOrder("RN2", "BOOKED", nil)
+
+	var removals []HistoryEntry
+	for _, entry := range m.HistoryEntries([]DetailedOrder{kept, removed}, []DetailedOrder{kept}, at) {
+		if entry.Field == OrderField {
+			removals = append(removals, entry)
+		}
+	}
+	want := HistoryEntry{Time: at, ReferenceNumber: "RN2", Field: OrderField, OldValue: "BOOKED", NewValue: OrderRemoved}
+	if len(removals) != 1 || removals[0] != want {
+		t.Errorf("HistoryEntries() removals = %+v, want [%+v]", removals, want)
+	}
+}
+
+func TestAppendAndLoadHistory(t *testing.T) {
+	m := &OrderManager{HistoryFile: filepath.Join(t.TempDir(), "history.jsonl")}
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	entries := []HistoryEntry{
+		{Time: at, ReferenceNumber: "RN1", Field: "Status", NewValue: "BOOKED"},
+		{Time: at, ReferenceNumber: "RN2", Field: OrderField, OldValue: "BOOKED", NewValue: OrderRemoved},
+	}
+	if err := m.AppendHistory(entries); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := m.LoadHistory("RN2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 1 || !loaded[0].Time.Equal(at) || loaded[0].Field != OrderField {
+		t.Errorf("LoadHistory(RN2) = %+v", loaded)
+	}
+}
@@ -0,0 +1,304 @@
+package tesla
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Profile is the identity of the Tesla account, taken from the id_token
+// received at login.
+type Profile struct {
+	Subject string `json:"sub,omitempty"`
+	Email   string `json:"email,omitempty"`
+	Name    string `json:"name,omitempty"`
+	// Verified is set when the id_token signature was checked against
+	// Tesla's published keys. Profiles saved while offline are unverified.
+	Verified  bool      `json:"verified"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Label returns the name to show for the profile, preferring the email.
+func (p Profile) Label() string {
+	switch {
+	case p.Name != "" && p.Email != "":
+		return fmt.Sprintf("%s <%s>", p.Name, p.Email)
+	case p.Email != "":
+		return p.Email
+	default:
+		return p.Name
+	}
+}
+
+// IDTokenClaims are the id_token claims the app uses.
+type IDTokenClaims struct {
+	Issuer     string   `json:"iss"`
+	Subject    string   `json:"sub"`
+	Audience   audience `json:"aud"`
+	Expiry     int64    `json:"exp"`
+	Email      string   `json:"email"`
+	Name       string   `json:"name"`
+	GivenName  string   `json:"given_name"`
+	FamilyName string   `json:"family_name"`
+}
+
+// audience accepts both forms of the aud claim: a string or a list.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// Profile returns the profile described by the claims.
+func (c IDTokenClaims) Profile() Profile {
+	name := c.Name
+	if name == "" {
+		name = strings.TrimSpace(c.GivenName + " " + c.FamilyName)
+	}
+	return Profile{Subject: c.Subject, Email: c.Email, Name: name}
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// ParseIDToken decodes the claims of an id_token without checking its
+// signature.
+func ParseIDToken(idToken string) (IDTokenClaims, error) {
+	var claims IDTokenClaims
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return claims, errors.New("id_token is not a JWT")
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return claims, fmt.Errorf("invalid id_token claims: %w", err)
+	}
+	return claims, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// errJWKSUnavailable wraps failures to fetch the signing keys, after which
+// the id_token is used unverified.
+var errJWKSUnavailable = errors.New("signing keys not available")
+
+// idTokenLeeway is the clock skew allowed when checking the expiry of an
+// id_token.
+const idTokenLeeway = 2 * time.Minute
+
+// VerifyIDToken checks the RS256 signature of idToken against the keys
+// published at the JWKS endpoint, that it was issued by the configured auth
+// server to this client and that it has not expired.
+func (a *TeslaAuth) VerifyIDToken(ctx context.Context, idToken string) error {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return errors.New("id_token is not a JWT")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return fmt.Errorf("invalid id_token header: %w", err)
+	}
+	if header.Alg != "RS256" {
+		return fmt.Errorf("unsupported id_token algorithm %q", header.Alg)
+	}
+
+	claims, err := ParseIDToken(idToken)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(claims.Audience, ClientID) {
+		return fmt.Errorf("id_token was issued to %v, not %s", []string(claims.Audience), ClientID)
+	}
+	if issuer := a.Endpoints.Issuer(); claims.Issuer != issuer {
+		return fmt.Errorf("id_token was issued by %q, not %q", claims.Issuer, issuer)
+	}
+	if expiry := time.Unix(claims.Expiry, 0); claims.Expiry == 0 || time.Now().After(expiry.Add(idTokenLeeway)) {
+		return fmt.Errorf("id_token expired at %s", expiry.Format(time.RFC3339))
+	}
+
+	key, err := a.signingKey(ctx, header.Kid)
+	if err != nil {
+		return err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[2], "="))
+	if err != nil {
+		return fmt.Errorf("invalid id_token signature: %w", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return fmt.Errorf("id_token signature does not match: %w", err)
+	}
+	return nil
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// jwksCache keeps the fetched signing keys by JWKS URL, so refreshing tokens
+// does not download them every time.
+var jwksCache = struct {
+	sync.Mutex
+	keys    map[string]map[string]*rsa.PublicKey
+	fetched map[string]time.Time
+}{
+	keys:    make(map[string]map[string]*rsa.PublicKey),
+	fetched: make(map[string]time.Time),
+}
+
+const jwksCacheLifetime = time.Hour
+
+func (a *TeslaAuth) signingKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	jwksURL := a.Endpoints.JWKSURL
+
+	jwksCache.Lock()
+	keys, ok := jwksCache.keys[jwksURL]
+	// A key missing from a cached set may have been rotated in since.
+	if ok && time.Since(jwksCache.fetched[jwksURL]) < jwksCacheLifetime && keys[kid] != nil {
+		jwksCache.Unlock()
+		return keys[kid], nil
+	}
+	jwksCache.Unlock()
+
+	keys, err := a.fetchJWKS(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errJWKSUnavailable, err)
+	}
+
+	jwksCache.Lock()
+	jwksCache.keys[jwksURL] = keys
+	jwksCache.fetched[jwksURL] = time.Now()
+	jwksCache.Unlock()
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("id_token signed with unknown key %q", kid)
+	}
+	return key, nil
+}
+
+func (a *TeslaAuth) fetchJWKS(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	resp, err := a.Client.R().
+		SetContext(ctx).
+		SetHeader("Accept", "application/json").
+		Get(a.Endpoints.JWKSURL)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != 200 {
+		return nil, fmt.Errorf("fetching %s: status %d", a.Endpoints.JWKSURL, resp.StatusCode())
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(resp.Body(), &set); err != nil {
+		return nil, fmt.Errorf("invalid key set: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+// updateProfile saves the profile of idToken to ProfileFile. An id_token
+// with a bad signature is ignored; one that cannot be checked because the
+// keys are unreachable is saved as unverified.
+func (a *TeslaAuth) updateProfile(ctx context.Context, idToken string) {
+	if idToken == "" || a.ProfileFile == "" {
+		return
+	}
+
+	claims, err := ParseIDToken(idToken)
+	if err != nil {
+		fmt.Printf("Warning: Could not read the id_token: %v\n", err)
+		return
+	}
+
+	profile := claims.Profile()
+	profile.UpdatedAt = time.Now()
+	switch err := a.VerifyIDToken(ctx, idToken); {
+	case err == nil:
+		profile.Verified = true
+	case errors.Is(err, errJWKSUnavailable):
+		fmt.Printf("Warning: Could not verify the id_token, saving the profile unverified: %v\n", err)
+	default:
+		fmt.Printf("Warning: Ignoring the id_token: %v\n", err)
+		return
+	}
+
+	if err := SaveProfile(a.ProfileFile, profile); err != nil {
+		fmt.Printf("Warning: Could not save the account profile: %v\n", err)
+	}
+}
+
+// LoadProfile reads a saved profile. It returns the zero Profile without an
+// error when none is saved.
+func LoadProfile(path string) (Profile, error) {
+	var profile Profile
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return profile, nil
+	}
+	if err != nil {
+		return profile, err
+	}
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return profile, fmt.Errorf("invalid profile file %s: %w", path, err)
+	}
+	return profile, nil
+}
+
+func SaveProfile(path string, profile Profile) error {
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
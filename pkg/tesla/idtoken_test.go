@@ -0,0 +1,235 @@
+package tesla
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"maps"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func signedIDToken(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// newJWKSAuth returns a TeslaAuth whose JWKS endpoint publishes key as kid.
+func newJWKSAuth(t *testing.T, key *rsa.PrivateKey, kid string) *TeslaAuth {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": kid,
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	auth := NewTeslaAuth()
+	auth.Endpoints.JWKSURL = server.URL
+	auth.ProfileFile = filepath.Join(t.TempDir(), "profile.json")
+	auth.Client.SetRetryCount(0)
+	return auth
+}
+
+var testProfileClaims = map[string]interface{}{
+	"iss":   "https://auth.tesla.com/oauth2/v3",
+	"sub":   "user-1",
+	"aud":   []string{ClientID, "other"},
+	"exp":   time.Now().Add(8 * time.Hour).Unix(),
+	"email": "driver@example.com",
+	"name":  "Test Driver",
+}
+
+// withClaims returns testProfileClaims with the given claims replaced.
+func withClaims(changes map[string]interface{}) map[string]interface{} {
+	claims := maps.Clone(testProfileClaims)
+	maps.Copy(claims, changes)
+	return claims
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := newJWKSAuth(t, key, "k1")
+
+	if err := auth.VerifyIDToken(t.Context(), signedIDToken(t, key, "k1", testProfileClaims)); err != nil {
+		t.Errorf("VerifyIDToken() valid token error = %v", err)
+	}
+
+	if err := auth.VerifyIDToken(t.Context(), signedIDToken(t, other, "k1", testProfileClaims)); err == nil {
+		t.Error("VerifyIDToken() accepted a token signed with another key")
+	}
+
+	if err := auth.VerifyIDToken(t.Context(), signedIDToken(t, key, "k2", testProfileClaims)); err == nil {
+		t.Error("VerifyIDToken() accepted a token with an unknown key id")
+	}
+
+	foreign := map[string]interface{}{"sub": "user-1", "aud": "someone-else"}
+	if err := auth.VerifyIDToken(t.Context(), signedIDToken(t, key, "k1", foreign)); err == nil {
+		t.Error("VerifyIDToken() accepted a token issued to another client")
+	}
+
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+		valid  bool
+	}{
+		{"expired", withClaims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()}), false},
+		{"expired within leeway", withClaims(map[string]interface{}{"exp": time.Now().Add(-time.Minute).Unix()}), true},
+		{"without expiry", withClaims(map[string]interface{}{"exp": nil}), false},
+		{"foreign issuer", withClaims(map[string]interface{}{"iss": "https://auth.example.com/oauth2/v3"}), false},
+		{"without issuer", withClaims(map[string]interface{}{"iss": nil}), false},
+	}
+	for _, tt := range tests {
+		err := auth.VerifyIDToken(t.Context(), signedIDToken(t, key, "k1", tt.claims))
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: VerifyIDToken() error = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+
+	auth.Endpoints.JWKSURL = "http://127.0.0.1:1/keys"
+	if err := auth.VerifyIDToken(t.Context(), signedIDToken(t, key, "k1", testProfileClaims)); !errors.Is(err, errJWKSUnavailable) {
+		t.Errorf("VerifyIDToken() offline error = %v, want errJWKSUnavailable", err)
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := newJWKSAuth(t, key, "k1")
+	auth.updateProfile(t.Context(), signedIDToken(t, key, "k1", testProfileClaims))
+	profile, err := LoadProfile(auth.ProfileFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !profile.Verified || profile.Email != "driver@example.com" || profile.Name != "Test Driver" || profile.Subject != "user-1" {
+		t.Errorf("profile = %+v, want the verified claims", profile)
+	}
+
+	forged := newJWKSAuth(t, key, "k1")
+	forged.updateProfile(t.Context(), signedIDToken(t, other, "k1", testProfileClaims))
+	if profile, _ := LoadProfile(forged.ProfileFile); profile != (Profile{}) {
+		t.Errorf("profile from a forged id_token was saved: %+v", profile)
+	}
+
+	offline := newJWKSAuth(t, key, "k1")
+	offline.Endpoints.JWKSURL = "http://127.0.0.1:1/keys"
+	offline.updateProfile(t.Context(), signedIDToken(t, key, "k1", testProfileClaims))
+	profile, _ = LoadProfile(offline.ProfileFile)
+	if profile.Verified || profile.Email != "driver@example.com" {
+		t.Errorf("offline profile = %+v, want the unverified claims", profile)
+	}
+}
+
+func TestParseIDToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := ParseIDToken(signedIDToken(t, key, "k1", map[string]interface{}{
+		"aud":         ClientID,
+		"email":       "driver@example.com",
+		"given_name":  "Test",
+		"family_name": "Driver",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claims.Audience) != 1 || claims.Audience[0] != ClientID {
+		t.Errorf("Audience = %v, want [%s]", claims.Audience, ClientID)
+	}
+	if got := claims.Profile().Label(); got != "Test Driver <driver@example.com>" {
+		t.Errorf("Label() = %q", got)
+	}
+
+	if _, err := ParseIDToken("not-a-jwt"); err == nil {
+		t.Error("ParseIDToken() accepted a malformed token")
+	}
+}
+
+func TestRefreshVerifiesProfileOutsideLock(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestManager(t, &testServer{refresh: func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken: "access-2",
+			IdToken:     signedIDToken(t, key, "k1", testProfileClaims),
+		})
+	}})
+	auth := m.Auth
+	auth.Endpoints.AuthURL = AuthURL
+
+	requested, release := make(chan struct{}), make(chan struct{})
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requested)
+		<-release
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(jwks.Close)
+	auth.Endpoints.JWKSURL = jwks.URL
+	auth.Client.SetRetryCount(0)
+
+	done := make(chan error)
+	go func() { done <- auth.RefreshTokens(t.Context()) }()
+
+	<-requested
+	token := make(chan string)
+	go func() { token <- auth.currentAccessToken() }()
+	select {
+	case got := <-token:
+		if got != "access-2" {
+			t.Errorf("access token during the key fetch = %q, want access-2", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("the key fetch blocked the access token")
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
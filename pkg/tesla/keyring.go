@@ -0,0 +1,67 @@
+package tesla
+
+import (
+	"errors"
+	"fmt"
+)
+
+// KeyringService is the service name the tokens are filed under in the OS
+// keyring.
+const KeyringService = "tesla-tracking-app"
+
+// errKeyringUnavailable is returned when the platform has no usable secret
+// store, for example a Linux system without secret-tool.
+var errKeyringUnavailable = errors.New("OS keyring not available")
+
+// errKeyringNotFound is returned by keyringGet for missing entries.
+var errKeyringNotFound = errors.New("keyring entry not found")
+
+// KeyringStore keeps the tokens in the platform secret store: the Secret
+// Service (libsecret) on Linux, the Keychain on macOS and the Credential
+// Manager on Windows. The access and refresh tokens are separate entries;
+// on Windows, which limits a credential to 2560 bytes, a token longer than
+// that is split across several credentials.
+type KeyringStore struct {
+	Service string
+	User    string
+}
+
+func (s *KeyringStore) key(name string) string {
+	return s.User + "#" + name
+}
+
+func (s *KeyringStore) Load() (Tokens, error) {
+	refreshToken, err := keyringGet(s.Service, s.key("refresh_token"))
+	if errors.Is(err, errKeyringNotFound) {
+		return Tokens{}, ErrNoTokens
+	}
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	accessToken, err := keyringGet(s.Service, s.key("access_token"))
+	if err != nil && !errors.Is(err, errKeyringNotFound) {
+		return Tokens{}, err
+	}
+
+	return Tokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+func (s *KeyringStore) Save(tokens Tokens) error {
+	if err := keyringSet(s.Service, s.key("access_token"), tokens.AccessToken); err != nil {
+		return fmt.Errorf("saving access token to the keyring: %w", err)
+	}
+	if err := keyringSet(s.Service, s.key("refresh_token"), tokens.RefreshToken); err != nil {
+		return fmt.Errorf("saving refresh token to the keyring: %w", err)
+	}
+	return nil
+}
+
+func (s *KeyringStore) Delete() error {
+	for _, name := range []string{"access_token", "refresh_token"} {
+		if err := keyringDelete(s.Service, s.key(name)); err != nil && !errors.Is(err, errKeyringNotFound) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+//go:build !windows
+
+package tesla
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// macOS "security" exits with this status when an item does not exist.
+const securityItemNotFound = 44
+
+func keyringGet(service, user string) (string, error) {
+	if runtime.GOOS == "darwin" {
+		out, err := runKeyringCommand(nil, "security", "find-generic-password", "-s", service, "-a", user, "-w")
+		if exitCode(err) == securityItemNotFound {
+			return "", errKeyringNotFound
+		}
+		return strings.TrimSuffix(out, "\n"), err
+	}
+
+	out, err := runKeyringCommand(nil, "secret-tool", "lookup", "service", service, "account", user)
+	// secret-tool fails silently when the item does not exist, and with a
+	// message when the Secret Service cannot be reached.
+	if errors.Is(err, errKeyringSilentFailure) {
+		return "", errKeyringNotFound
+	}
+	return out, err
+}
+
+func keyringSet(service, user, secret string) error {
+	if runtime.GOOS == "darwin" {
+		// The secret goes through the interactive mode's stdin, hex
+		// encoded, so it never shows up in the process list.
+		command := fmt.Sprintf("add-generic-password -U -s %q -a %q -X %s\n", service, user, hex.EncodeToString([]byte(secret)))
+		_, err := runKeyringCommand(strings.NewReader(command), "security", "-i")
+		return err
+	}
+
+	_, err := runKeyringCommand(strings.NewReader(secret), "secret-tool", "store",
+		"--label=Tesla Tracking ("+user+")", "service", service, "account", user)
+	return err
+}
+
+func keyringDelete(service, user string) error {
+	if runtime.GOOS == "darwin" {
+		_, err := runKeyringCommand(nil, "security", "delete-generic-password", "-s", service, "-a", user)
+		if exitCode(err) == securityItemNotFound {
+			return errKeyringNotFound
+		}
+		return err
+	}
+
+	_, err := runKeyringCommand(nil, "secret-tool", "clear", "service", service, "account", user)
+	return err
+}
+
+func runKeyringCommand(stdin *strings.Reader, name string, args ...string) (string, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", errKeyringUnavailable
+	}
+
+	cmd := exec.Command(path, args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return "", err
+		}
+		if message := bytes.TrimSpace(stderr.Bytes()); len(message) > 0 {
+			return "", fmt.Errorf("%s: %w: %s", name, err, message)
+		}
+		return "", fmt.Errorf("%s: %w: %w", name, errKeyringSilentFailure, err)
+	}
+	return stdout.String(), nil
+}
+
+// errKeyringSilentFailure is returned by runKeyringCommand when the command
+// fails without writing anything to stderr.
+var errKeyringSilentFailure = errors.New("failed without a message")
+
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return 0
+}
@@ -0,0 +1,64 @@
+//go:build !windows
+
+package tesla
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeSecretTool puts a secret-tool on PATH that runs script.
+func fakeSecretTool(t *testing.T, script string) {
+	t.Helper()
+	if runtime.GOOS == "darwin" {
+		t.Skip("macOS uses the security command")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "secret-tool"), []byte("#!/bin/sh\n"+script+"\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestKeyringGet(t *testing.T) {
+	// secret-tool prints the secret without a trailing newline.
+	fakeSecretTool(t, `printf "token-%s" "$5"`)
+
+	got, err := keyringGet(KeyringService, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "token-user" {
+		t.Errorf("keyringGet() = %q, want token-user", got)
+	}
+}
+
+func TestKeyringGetMissingEntry(t *testing.T) {
+	fakeSecretTool(t, "exit 1")
+
+	if _, err := keyringGet(KeyringService, "user"); !errors.Is(err, errKeyringNotFound) {
+		t.Errorf("keyringGet() error = %v, want errKeyringNotFound", err)
+	}
+}
+
+func TestKeyringGetServiceError(t *testing.T) {
+	fakeSecretTool(t, `echo "Cannot autolaunch D-Bus without X11 \$DISPLAY" >&2; exit 1`)
+
+	_, err := keyringGet(KeyringService, "user")
+	if err == nil || errors.Is(err, errKeyringNotFound) {
+		t.Fatalf("keyringGet() error = %v, want the secret-tool failure", err)
+	}
+	if !strings.Contains(err.Error(), "Cannot autolaunch D-Bus") {
+		t.Errorf("keyringGet() error = %v, want the stderr output", err)
+	}
+
+	store := &KeyringStore{Service: KeyringService, User: "user"}
+	if _, err := store.Load(); err == nil || errors.Is(err, ErrNoTokens) {
+		t.Errorf("Load() error = %v, want the secret-tool failure rather than no tokens", err)
+	}
+}
@@ -0,0 +1,171 @@
+//go:build windows
+
+package tesla
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32   = syscall.NewLazyDLL("advapi32.dll")
+	credWrite  = advapi32.NewProc("CredWriteW")
+	credRead   = advapi32.NewProc("CredReadW")
+	credDelete = advapi32.NewProc("CredDeleteW")
+	credFree   = advapi32.NewProc("CredFree")
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = syscall.Errno(1168)
+
+	// credMaxBlobSize is CRED_MAX_CREDENTIAL_BLOB_SIZE, the most a single
+	// credential holds. Longer secrets are split across several
+	// credentials.
+	credMaxBlobSize = 5 * 512
+)
+
+// credential mirrors the CREDENTIALW structure.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// credentialTarget names the credential holding part of a secret. The first
+// part keeps the plain name, so secrets saved before they were split still
+// load.
+func credentialTarget(service, user string, part int) (*uint16, error) {
+	if part == 0 {
+		return syscall.UTF16PtrFromString(service + ":" + user)
+	}
+	return syscall.UTF16PtrFromString(fmt.Sprintf("%s:%s#%d", service, user, part))
+}
+
+// keyringGet joins the parts of a secret. A part shorter than
+// credMaxBlobSize is the last one.
+func keyringGet(service, user string) (string, error) {
+	var secret []byte
+	for part := 0; ; part++ {
+		blob, err := credentialRead(service, user, part)
+		if errors.Is(err, errKeyringNotFound) && part > 0 {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		secret = append(secret, blob...)
+		if len(blob) < credMaxBlobSize {
+			break
+		}
+	}
+	return string(secret), nil
+}
+
+func credentialRead(service, user string, part int) ([]byte, error) {
+	target, err := credentialTarget(service, user, part)
+	if err != nil {
+		return nil, err
+	}
+
+	var cred *credential
+	ret, _, err := credRead.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if ret == 0 {
+		if errors.Is(err, errorNotFound) {
+			return nil, errKeyringNotFound
+		}
+		return nil, err
+	}
+	defer credFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	return append([]byte(nil), unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)...), nil
+}
+
+// keyringSet saves secret in parts of at most credMaxBlobSize bytes and
+// removes the parts left over from a longer secret saved before.
+func keyringSet(service, user, secret string) error {
+	blob := []byte(secret)
+	part := 0
+	for ; part == 0 || len(blob) > 0; part++ {
+		size := min(len(blob), credMaxBlobSize)
+		if err := credentialWrite(service, user, part, blob[:size]); err != nil {
+			return fmt.Errorf("writing part %d of the credential: %w", part+1, err)
+		}
+		blob = blob[size:]
+	}
+	return deleteParts(service, user, part)
+}
+
+func credentialWrite(service, user string, part int, blob []byte) error {
+	target, err := credentialTarget(service, user, part)
+	if err != nil {
+		return err
+	}
+	userName, err := syscall.UTF16PtrFromString(user)
+	if err != nil {
+		return err
+	}
+
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocalMachine,
+		UserName:           userName,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+
+	if ret, _, err := credWrite.Call(uintptr(unsafe.Pointer(&cred)), 0); ret == 0 {
+		return err
+	}
+	return nil
+}
+
+func keyringDelete(service, user string) error {
+	if err := credentialDelete(service, user, 0); err != nil {
+		return err
+	}
+	return deleteParts(service, user, 1)
+}
+
+// deleteParts deletes the parts of a secret from part on.
+func deleteParts(service, user string, part int) error {
+	for ; ; part++ {
+		err := credentialDelete(service, user, part)
+		if errors.Is(err, errKeyringNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func credentialDelete(service, user string, part int) error {
+	target, err := credentialTarget(service, user, part)
+	if err != nil {
+		return err
+	}
+
+	if ret, _, err := credDelete.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0); ret == 0 {
+		if errors.Is(err, errorNotFound) {
+			return errKeyringNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+//go:build windows
+
+package tesla
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKeyringSplitsLongSecrets(t *testing.T) {
+	service, user := KeyringService+"-test", t.Name()
+	t.Cleanup(func() { keyringDelete(service, user) })
+
+	long := strings.Repeat("a", 2*credMaxBlobSize+100)
+	if err := keyringSet(service, user, long); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := keyringGet(service, user); err != nil || got != long {
+		t.Fatalf("keyringGet() = %d bytes, %v, want %d bytes", len(got), err, len(long))
+	}
+
+	// A shorter secret must not pick up the parts left by the longer one.
+	short := strings.Repeat("b", credMaxBlobSize)
+	if err := keyringSet(service, user, short); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := keyringGet(service, user); err != nil || got != short {
+		t.Fatalf("keyringGet() = %d bytes, %v, want %d bytes", len(got), err, len(short))
+	}
+
+	if err := keyringDelete(service, user); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keyringGet(service, user); !errors.Is(err, errKeyringNotFound) {
+		t.Errorf("keyringGet() after delete = %v, want errKeyringNotFound", err)
+	}
+}
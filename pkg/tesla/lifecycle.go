@@ -0,0 +1,160 @@
+package tesla
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Stage is a step of the order lifecycle, derived from the order status and
+// the task fields since Tesla does not report it directly.
+type Stage string
+
+const (
+	StageReserved             Stage = "reserved"
+	StageBooked               Stage = "booked"
+	StageVINAssigned          Stage = "vin_assigned"
+	StageInTransit            Stage = "in_transit"
+	StageAtDeliveryCenter     Stage = "at_delivery_center"
+	StageAppointmentScheduled Stage = "appointment_scheduled"
+	StageDelivered            Stage = "delivered"
+)
+
+// Stages lists the lifecycle in order.
+var Stages = []Stage{
+	StageReserved,
+	StageBooked,
+	StageVINAssigned,
+	StageInTransit,
+	StageAtDeliveryCenter,
+	StageAppointmentScheduled,
+	StageDelivered,
+}
+
+// StageField is the HistoryEntry field under which stage transitions are
+// recorded.
+const StageField = "Stage"
+
+// Index returns the position of the stage in Stages, or -1 if it is not
+// one of them.
+func (s Stage) Index() int {
+	return slices.Index(Stages, s)
+}
+
+// OrderStage derives the lifecycle stage of order at the time now. Each
+// stage implies the ones before it, so the furthest one with evidence wins:
+//
+//   - delivered: the status is DELIVERED or delivery acceptance is complete
+//   - appointment scheduled: there is a delivery appointment
+//   - at delivery center: the ETA to the delivery center has passed
+//   - in transit: the vehicle has a VIN and an ETA or a delivery center
+//   - VIN assigned: the order has a VIN
+//   - booked: the status is BOOKED or the order has a booked date
+func OrderStage(order DetailedOrder, now time.Time) Stage {
+	status := strings.ToUpper(order.Order.OrderStatus)
+	tasks := order.Details.TypedTasks()
+
+	if status == "DELIVERED" {
+		return StageDelivered
+	}
+	for _, item := range order.Details.Checklist() {
+		if item.Key == "deliveryAcceptance" && item.Progress == TaskComplete {
+			return StageDelivered
+		}
+	}
+
+	if scheduling := tasks.Scheduling; scheduling != nil {
+		if appointment := scheduling.ApptDateTimeAddressStr; appointment.Valid && strings.TrimSpace(appointment.Value) != "" {
+			return StageAppointmentScheduled
+		}
+	}
+
+	if order.Order.VIN != "" {
+		var eta string
+		if finalPayment := tasks.FinalPayment; finalPayment != nil && finalPayment.Data != nil && finalPayment.Data.ETAToDeliveryCenter.Valid {
+			eta = strings.TrimSpace(finalPayment.Data.ETAToDeliveryCenter.Value)
+		}
+
+		if arrival, ok := ParseETA(eta, now); ok && !arrival.After(now) {
+			return StageAtDeliveryCenter
+		}
+		if eta != "" || RoutingLocation(order) != 0 {
+			return StageInTransit
+		}
+		return StageVINAssigned
+	}
+
+	booked := status == "BOOKED"
+	if registration := tasks.Registration; registration != nil && registration.OrderDetails != nil {
+		booked = booked || (registration.OrderDetails.OrderBookedDate.Valid && registration.OrderDetails.OrderBookedDate.Value != "")
+	}
+	if booked {
+		return StageBooked
+	}
+	return StageReserved
+}
+
+// etaLayouts are the forms Tesla has been seen to use for dates. Layouts
+// without a year are taken in the year closest to now.
+var etaLayouts = []struct {
+	layout  string
+	hasYear bool
+}{
+	{time.RFC3339, true},
+	{"2006-01-02T15:04:05.000Z", true},
+	{"2006-01-02", true},
+	{"Jan 2, 2006", true},
+	{"January 2, 2006", true},
+	{"2 Jan 2006", true},
+	{"02.01.2006", true},
+	{"Jan 2", false},
+	{"January 2", false},
+	{"2 Jan", false},
+}
+
+// ParseETA parses a date such as the ETA to the delivery center. The result
+// is the end of that day in now's location, so a vehicle due today counts
+// as arrived only once the day is over.
+func ParseETA(s string, now time.Time) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+
+	for _, l := range etaLayouts {
+		t, err := time.ParseInLocation(l.layout, s, now.Location())
+		if err != nil {
+			continue
+		}
+
+		day := time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, now.Location())
+		if !l.hasYear {
+			day = day.AddDate(now.Year()-t.Year(), 0, 0)
+			// "Jan 3" seen in late December is next year's, "Dec 28" seen
+			// in early January last year's.
+			if day.Sub(now) > 183*24*time.Hour {
+				day = day.AddDate(-1, 0, 0)
+			} else if now.Sub(day) > 183*24*time.Hour {
+				day = day.AddDate(1, 0, 0)
+			}
+		}
+		return day, true
+	}
+	return time.Time{}, false
+}
+
+// StageTimes returns when each stage was first reached according to the
+// recorded history entries of one order.
+func StageTimes(entries []HistoryEntry) map[Stage]time.Time {
+	times := make(map[Stage]time.Time)
+	for _, entry := range entries {
+		if entry.Field != StageField {
+			continue
+		}
+		stage := Stage(entry.NewValue)
+		if _, seen := times[stage]; !seen {
+			times[stage] = entry.Time
+		}
+	}
+	return times
+}
@@ -0,0 +1,204 @@
+package tesla
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOrderStage(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	registration := map[string]interface{}{
+		"orderDetails": map[string]interface{}{"orderBookedDate": "2026-01-02", "vehicleRoutingLocation": 2614.0},
+	}
+	eta := func(value string) map[string]interface{} {
+		return map[string]interface{}{"data": map[string]interface{}{"etaToDeliveryCenter": value}}
+	}
+
+	tests := []struct {
+		name  string
+		order DetailedOrder
+		want  Stage
+	}{
+		{"reserved", testOrder("RN1", "RESERVED", nil), StageReserved},
+		{"booked by status", testOrder("RN1", "BOOKED", nil), StageBooked},
+		{"booked by date", testOrder("RN1", "RESERVED", map[string]interface{}{"registration": registration}), StageBooked},
+		{"vin assigned", withVIN(testOrder("RN1", "BOOKED", nil)), StageVINAssigned},
+		{"in transit by routing", withVIN(testOrder("RN1", "BOOKED", map[string]interface{}{"registration": registration})), StageInTransit},
+		{"in transit by eta", withVIN(testOrder("RN1", "BOOKED", map[string]interface{}{"finalPayment": eta("Mar 12")})), StageInTransit},
+		{"at delivery center", withVIN(testOrder("RN1", "BOOKED", map[string]interface{}{"finalPayment": eta("Mar 9")})), StageAtDeliveryCenter},
+		{"appointment", withVIN(testOrder("RN1", "BOOKED", map[string]interface{}{
+			"scheduling": map[string]interface{}{"apptDateTimeAddressStr": "Mar 14, 10:00, Tesla München"},
+		})), StageAppointmentScheduled},
+		{"empty appointment", testOrder("RN1", "BOOKED", map[string]interface{}{
+			"scheduling": map[string]interface{}{"apptDateTimeAddressStr": nil},
+		}), StageBooked},
+		{"delivered by status", testOrder("RN1", "DELIVERED", nil), StageDelivered},
+		{"delivered by acceptance", withVIN(testOrder("RN1", "BOOKED", map[string]interface{}{
+			"deliveryAcceptance": map[string]interface{}{"complete": true},
+		})), StageDelivered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OrderStage(tt.order, now); got != tt.want {
+				t.Errorf("OrderStage() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func withVIN(order DetailedOrder) DetailedOrder {
+	order.Order.VIN = "XP7YGCEK4SB000001"
+	return order
+}
+
+func TestParseETA(t *testing.T) {
+	now := time.Date(2026, 12, 28, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"2027-01-05", "2027-01-05"},
+		{"Jan 5", "2027-01-05"},
+		{"Dec 20", "2026-12-20"},
+		{"March 3, 2027", "2027-03-03"},
+		{"2026-12-24T09:00:00.000Z", "2026-12-24"},
+	}
+	for _, tt := range tests {
+		got, ok := ParseETA(tt.input, now)
+		if !ok || got.Format("2006-01-02") != tt.want {
+			t.Errorf("ParseETA(%q) = %v, %v, want %s", tt.input, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := ParseETA("soon", now); ok {
+		t.Error("ParseETA(soon) succeeded")
+	}
+}
+
+// recordHistory returns the entries between the snapshots after appending
+// them to the history, as SaveOrdersToFile does.
+func recordHistory(t *testing.T, m *OrderManager, old, new []DetailedOrder, at time.Time) []HistoryEntry {
+	t.Helper()
+	entries := m.HistoryEntries(old, new, at)
+	if err := m.AppendHistory(entries); err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestHistoryEntriesRecordStages(t *testing.T) {
+	m := &OrderManager{HistoryFile: filepath.Join(t.TempDir(), "history.jsonl")}
+	at := time.Now()
+
+	booked := testOrder("RN1", "BOOKED", nil)
+	entries := recordHistory(t, m, nil, []DetailedOrder{booked}, at.Add(-time.Hour))
+	if stage := stageEntry(entries); stage == nil || stage.OldValue != "" || stage.NewValue != string(StageBooked) {
+		t.Fatalf("first snapshot stage entry = %+v", stage)
+	}
+
+	if stage := stageEntry(recordHistory(t, m, []DetailedOrder{booked}, []DetailedOrder{booked}, at)); stage != nil {
+		t.Errorf("unchanged order recorded stage %+v", stage)
+	}
+
+	entries = recordHistory(t, m, []DetailedOrder{booked}, []DetailedOrder{withVIN(booked)}, at)
+	stage := stageEntry(entries)
+	if stage == nil || stage.OldValue != string(StageBooked) || stage.NewValue != string(StageVINAssigned) {
+		t.Fatalf("VIN assignment stage entry = %+v", stage)
+	}
+
+	history, err := m.LoadHistory("RN1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	times := StageTimes(history)
+	if !times[StageBooked].Equal(at.Add(-time.Hour)) || !times[StageVINAssigned].Equal(at) {
+		t.Errorf("StageTimes() = %v", times)
+	}
+}
+
+func TestHistoryEntriesRecordStageChangedByTime(t *testing.T) {
+	m := &OrderManager{HistoryFile: filepath.Join(t.TempDir(), "history.jsonl")}
+	before := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	after := before.AddDate(0, 0, 3)
+
+	order := withVIN(testOrder("RN1", "BOOKED", map[string]interface{}{
+		"finalPayment": map[string]interface{}{"data": map[string]interface{}{"etaToDeliveryCenter": "2026-03-12"}},
+	}))
+	recordHistory(t, m, nil, []DetailedOrder{order}, before)
+
+	// The snapshots are the same; only the ETA has passed.
+	entries := recordHistory(t, m, []DetailedOrder{order}, []DetailedOrder{order}, after)
+	stage := stageEntry(entries)
+	if stage == nil || stage.OldValue != string(StageInTransit) || stage.NewValue != string(StageAtDeliveryCenter) {
+		t.Fatalf("stage entry after the ETA = %+v", stage)
+	}
+
+	history, err := m.LoadHistory("RN1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := StageTimes(history)[StageAtDeliveryCenter]; !got.Equal(after) {
+		t.Errorf("at delivery center since %v, want %v", got, after)
+	}
+}
+
+func TestHistoryEntriesRecordStageOfExistingOrders(t *testing.T) {
+	m := &OrderManager{HistoryFile: filepath.Join(t.TempDir(), "history.jsonl")}
+	// A history written before stages were recorded.
+	if err := m.AppendHistory([]HistoryEntry{{Time: time.Now().Add(-time.Hour), ReferenceNumber: "RN1", Field: "Status", NewValue: "BOOKED"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	booked := testOrder("RN1", "BOOKED", nil)
+	stage := stageEntry(recordHistory(t, m, []DetailedOrder{booked}, []DetailedOrder{booked}, time.Now()))
+	if stage == nil || stage.OldValue != "" || stage.NewValue != string(StageBooked) {
+		t.Errorf("stage entry of an existing order = %+v, want its current stage", stage)
+	}
+}
+
+func TestRecordedStageTimes(t *testing.T) {
+	m := &OrderManager{HistoryFile: filepath.Join(t.TempDir(), "history.jsonl")}
+	at := time.Now()
+
+	booked := testOrder("RN1", "BOOKED", nil)
+	recordHistory(t, m, nil, []DetailedOrder{booked}, at.Add(-time.Hour))
+	recordHistory(t, m, []DetailedOrder{booked}, []DetailedOrder{withVIN(booked)}, at)
+
+	history, err := m.LoadHistory("RN1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	times, err := m.RecordedStageTimes("RN1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := StageTimes(history); len(times) != len(want) || !times[StageBooked].Equal(want[StageBooked]) || !times[StageVINAssigned].Equal(want[StageVINAssigned]) {
+		t.Errorf("RecordedStageTimes() = %v, want %v", times, want)
+	}
+
+	// The stages are kept in memory once read: losing the file does not
+	// make the order look new.
+	if err := os.Remove(m.HistoryFile); err != nil {
+		t.Fatal(err)
+	}
+	if stage := stageEntry(m.HistoryEntries([]DetailedOrder{withVIN(booked)}, []DetailedOrder{withVIN(booked)}, at)); stage != nil {
+		t.Errorf("unchanged order recorded stage %+v", stage)
+	}
+	if times, err := m.RecordedStageTimes("RN1"); err != nil || !times[StageVINAssigned].Equal(at) {
+		t.Errorf("RecordedStageTimes() after removing the file = %v, %v", times, err)
+	}
+}
+
+func stageEntry(entries []HistoryEntry) *HistoryEntry {
+	for i := range entries {
+		if entries[i].Field == StageField {
+			return &entries[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package tesla
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Location is a point on the map with a free-form label, such as the
+// user's home address.
+type Location struct {
+	Label     string  `json:"label,omitempty"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// IsZero reports whether the location has no coordinates.
+func (l Location) IsZero() bool {
+	return l.Latitude == 0 && l.Longitude == 0
+}
+
+const earthRadiusKm = 6371.0
+
+// DistanceKm returns the great-circle distance between l and other.
+func (l Location) DistanceKm(other Location) float64 {
+	lat1, lat2 := l.Latitude*math.Pi/180, other.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (other.Longitude - l.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// ParseCoordinates parses "latitude, longitude" in decimal degrees, the
+// form map applications copy to the clipboard.
+func ParseCoordinates(s string) (latitude, longitude float64, err error) {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' || r == ' ' })
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid coordinates %q, expected \"latitude, longitude\"", s)
+	}
+
+	latitude, errLat := strconv.ParseFloat(parts[0], 64)
+	longitude, errLon := strconv.ParseFloat(parts[1], 64)
+	if errLat != nil || errLon != nil {
+		return 0, 0, fmt.Errorf("invalid coordinates %q, expected \"latitude, longitude\"", s)
+	}
+	if math.Abs(latitude) > 90 || math.Abs(longitude) > 180 {
+		return 0, 0, fmt.Errorf("coordinates %q are out of range", s)
+	}
+	return latitude, longitude, nil
+}
+
+// HomeFile returns the location of the saved home location, from which the
+// distance to the delivery center is measured.
+func HomeFile() string {
+	return filepath.Join(ConfigDir, "home.json")
+}
+
+// LoadHome reads the home location. It returns the zero Location without an
+// error when none is saved.
+func LoadHome(path string) (Location, error) {
+	var home Location
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return home, nil
+	}
+	if err != nil {
+		return home, err
+	}
+	if err := json.Unmarshal(data, &home); err != nil {
+		return home, fmt.Errorf("invalid home location %s: %w", path, err)
+	}
+	return home, nil
+}
+
+// SaveHome writes the home location, or removes the file if home is zero.
+func SaveHome(path string, home Location) error {
+	if home.IsZero() {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(home, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,57 @@
+package tesla
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDistanceKm(t *testing.T) {
+	istanbul := Location{Latitude: 41.0082, Longitude: 28.9784}
+	ankara := Location{Latitude: 39.9334, Longitude: 32.8597}
+
+	if got := istanbul.DistanceKm(ankara); math.Abs(got-350) > 5 {
+		t.Errorf("Istanbul-Ankara = %.1f km, want about 350", got)
+	}
+	if got := istanbul.DistanceKm(istanbul); got != 0 {
+		t.Errorf("distance to itself = %v", got)
+	}
+}
+
+func TestParseCoordinates(t *testing.T) {
+	for _, input := range []string{"41.0082, 28.9784", "41.0082,28.9784", " 41.0082 28.9784 "} {
+		lat, lon, err := ParseCoordinates(input)
+		if err != nil || lat != 41.0082 || lon != 28.9784 {
+			t.Errorf("ParseCoordinates(%q) = %v, %v, %v", input, lat, lon, err)
+		}
+	}
+	for _, input := range []string{"", "41.0", "north, east", "91, 0", "0, 181"} {
+		if _, _, err := ParseCoordinates(input); err == nil {
+			t.Errorf("ParseCoordinates(%q) succeeded", input)
+		}
+	}
+}
+
+func TestSaveHome(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "home.json")
+
+	if home, err := LoadHome(path); err != nil || !home.IsZero() {
+		t.Fatalf("LoadHome() without file = %+v, %v", home, err)
+	}
+
+	want := Location{Label: "Home", Latitude: 41.0082, Longitude: 28.9784}
+	if err := SaveHome(path, want); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := LoadHome(path); err != nil || got != want {
+		t.Errorf("LoadHome() = %+v, %v, want %+v", got, err, want)
+	}
+
+	if err := SaveHome(path, Location{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("saving a zero home kept the file: %v", err)
+	}
+}
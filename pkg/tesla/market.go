@@ -0,0 +1,148 @@
+package tesla
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Market is the country and language sent with the tasks request. Tesla
+// localizes the task texts and dates according to it.
+type Market struct {
+	Country  string `json:"country,omitempty"`
+	Language string `json:"language,omitempty"`
+}
+
+// DefaultMarket is used when neither the account nor the order tells the
+// market.
+var DefaultMarket = Market{Country: "DE", Language: "en"}
+
+// countryLanguages maps a country to the language assumed when the order
+// only reveals the country.
+var countryLanguages = map[string]string{
+	"AT": "de", "AU": "en", "BE": "nl", "CA": "en", "CH": "de", "CN": "zh",
+	"CZ": "cs", "DE": "de", "DK": "da", "ES": "es", "FI": "fi", "FR": "fr",
+	"GB": "en", "GR": "el", "HK": "zh", "HU": "hu", "IE": "en", "IT": "it",
+	"JP": "ja", "KR": "ko", "LU": "fr", "MX": "es", "NL": "nl", "NO": "no",
+	"NZ": "en", "PL": "pl", "PT": "pt", "RO": "ro", "SE": "sv", "SI": "sl",
+	"TR": "tr", "TW": "zh", "US": "en",
+}
+
+var (
+	countryPattern  = regexp.MustCompile(`^[A-Z]{2}$`)
+	languagePattern = regexp.MustCompile(`^[a-z]{2}$`)
+)
+
+// ParseMarket parses a market given as a country ("TR") or a locale
+// ("tr-TR", "tr_TR"). An empty string or "auto" returns the zero Market,
+// which lets the market be detected from the orders.
+func ParseMarket(s string) (Market, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "auto") {
+		return Market{}, nil
+	}
+
+	if market, ok := parseLocale(s); ok {
+		return market, nil
+	}
+
+	if !countryPattern.MatchString(strings.ToUpper(s)) {
+		return Market{}, fmt.Errorf("invalid market %q: use a country code such as TR or a locale such as tr-TR", s)
+	}
+	return NewMarket(s, "")
+}
+
+// NewMarket validates a country and language code, either of which may be
+// empty to detect it from the orders. Without a language, the main language
+// of the country is used.
+func NewMarket(country, language string) (Market, error) {
+	market := Market{
+		Country:  strings.ToUpper(strings.TrimSpace(country)),
+		Language: strings.ToLower(strings.TrimSpace(language)),
+	}
+
+	if market.Country != "" && !countryPattern.MatchString(market.Country) {
+		return Market{}, fmt.Errorf("invalid country %q: use a two-letter code such as TR", country)
+	}
+	if market.Language != "" && !languagePattern.MatchString(market.Language) {
+		return Market{}, fmt.Errorf("invalid language %q: use a two-letter code such as tr", language)
+	}
+
+	if market.Language == "" {
+		market.Language = countryLanguages[market.Country]
+	}
+	return market, nil
+}
+
+// parseLocale splits locales like "tr_TR" or "en-US".
+func parseLocale(s string) (Market, bool) {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == '_' || r == '-' })
+	if len(parts) != 2 {
+		return Market{}, false
+	}
+
+	market := Market{Country: strings.ToUpper(parts[1]), Language: strings.ToLower(parts[0])}
+	if !countryPattern.MatchString(market.Country) || !languagePattern.MatchString(market.Language) {
+		return Market{}, false
+	}
+	return market, true
+}
+
+// IsZero reports whether no part of the market is set.
+func (m Market) IsZero() bool {
+	return m.Country == "" && m.Language == ""
+}
+
+// Or fills the empty fields of m from fallback.
+func (m Market) Or(fallback Market) Market {
+	if m.Country == "" {
+		m.Country = fallback.Country
+	}
+	if m.Language == "" {
+		m.Language = fallback.Language
+	}
+	return m
+}
+
+// Locale returns the market as a locale such as "tr-TR".
+func (m Market) Locale() string {
+	return m.Language + "-" + m.Country
+}
+
+// UsesMiles reports whether distances are given in miles in the market.
+func (m Market) UsesMiles() bool {
+	return m.Country == "US" || m.Country == "GB"
+}
+
+func (m Market) String() string {
+	if m.IsZero() {
+		return "auto"
+	}
+	return m.Locale()
+}
+
+// DetectMarket derives the market from the order's country and locale, and
+// from the country in its registration details. Fields that cannot be
+// derived are left empty.
+func DetectMarket(order DetailedOrder) Market {
+	var market Market
+
+	if locale, ok := parseLocale(order.Order.Locale); ok {
+		market = locale
+	}
+	if country := strings.ToUpper(order.Order.CountryCode); countryPattern.MatchString(country) {
+		market.Country = country
+	}
+
+	if registration := order.Details.TypedTasks().Registration; market.Country == "" && registration != nil && registration.OrderDetails != nil {
+		if country := strings.ToUpper(registration.OrderDetails.CountryCode.Value); countryPattern.MatchString(country) {
+			market.Country = country
+		}
+	}
+
+	if market.Language == "" {
+		market.Language = countryLanguages[market.Country]
+	}
+
+	return market
+}
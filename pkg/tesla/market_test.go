@@ -0,0 +1,92 @@
+package tesla
+
+import "testing"
+
+func TestParseMarket(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Market
+		wantErr bool
+	}{
+		{"", Market{}, false},
+		{"auto", Market{}, false},
+		{"TR", Market{Country: "TR", Language: "tr"}, false},
+		{"us", Market{Country: "US", Language: "en"}, false},
+		{"tr_TR", Market{Country: "TR", Language: "tr"}, false},
+		{"en-GB", Market{Country: "GB", Language: "en"}, false},
+		{"AE", Market{Country: "AE"}, false},
+		{"Turkey", Market{}, true},
+		{"en-", Market{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseMarket(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMarket(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMarket(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewMarket(t *testing.T) {
+	tests := []struct {
+		country, language string
+		want              Market
+		wantErr           bool
+	}{
+		{"", "", Market{}, false},
+		{"tr", "", Market{Country: "TR", Language: "tr"}, false},
+		{"TR", "EN", Market{Country: "TR", Language: "en"}, false},
+		{"", "de", Market{Language: "de"}, false},
+		{"TUR", "", Market{}, true},
+		{"TR", "turkish", Market{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := NewMarket(tt.country, tt.language)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("NewMarket(%q, %q) error = %v, wantErr %v", tt.country, tt.language, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("NewMarket(%q, %q) = %+v, want %+v", tt.country, tt.language, got, tt.want)
+		}
+	}
+}
+
+func TestMarketFor(t *testing.T) {
+	registeredIn := func(country string) OrderDetails {
+		return OrderDetails{Tasks: map[string]interface{}{
+			"registration": map[string]interface{}{
+				"orderDetails": map[string]interface{}{"countryCode": country},
+			},
+		}}
+	}
+
+	tests := []struct {
+		name       string
+		configured Market
+		order      DetailedOrder
+		want       Market
+	}{
+		{"default", Market{}, DetailedOrder{}, DefaultMarket},
+		{"order locale", Market{}, DetailedOrder{Order: Order{Locale: "tr_TR"}}, Market{Country: "TR", Language: "tr"}},
+		{"order country", Market{}, DetailedOrder{Order: Order{CountryCode: "NL"}}, Market{Country: "NL", Language: "nl"}},
+		{"registration country", Market{}, DetailedOrder{Details: registeredIn("TR")}, Market{Country: "TR", Language: "tr"}},
+		{"unknown country", Market{}, DetailedOrder{Order: Order{CountryCode: "AE"}}, Market{Country: "AE", Language: "en"}},
+		{"configured", Market{Country: "US", Language: "en"}, DetailedOrder{Order: Order{Locale: "tr_TR"}}, Market{Country: "US", Language: "en"}},
+		{"configured language", Market{Language: "en"}, DetailedOrder{Order: Order{Locale: "tr_TR"}}, Market{Country: "TR", Language: "en"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &OrderManager{Market: tt.configured}
+			if got := m.MarketFor(tt.order); got != tt.want {
+				t.Errorf("MarketFor() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package tesla
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+//go:embed data/options.json
+var bundledOptions []byte
+
+// OptionCategory groups the option codes shown in the configuration tab.
+type OptionCategory string
+
+const (
+	OptionPaint     OptionCategory = "paint"
+	OptionInterior  OptionCategory = "interior"
+	OptionWheels    OptionCategory = "wheels"
+	OptionAutopilot OptionCategory = "autopilot"
+	OptionTrim      OptionCategory = "trim"
+	OptionOther     OptionCategory = "other"
+)
+
+// OptionCategories lists the categories in the order they are shown.
+var OptionCategories = []OptionCategory{OptionTrim, OptionPaint, OptionInterior, OptionWheels, OptionAutopilot, OptionOther}
+
+// Option is a decoded option code. Name is empty for codes the table does
+// not know.
+type Option struct {
+	Code     string         `json:"code"`
+	Category OptionCategory `json:"category"`
+	Name     string         `json:"name,omitempty"`
+}
+
+// Label returns the name followed by the code, or the bare code if the
+// name is unknown.
+func (o Option) Label() string {
+	if o.Name == "" {
+		return o.Code
+	}
+	return fmt.Sprintf("%s (%s)", o.Name, o.Code)
+}
+
+// OptionTable maps model codes to model names and option codes to their
+// category and name.
+type OptionTable struct {
+	Version string            `json:"version,omitempty"`
+	Models  map[string]string `json:"models,omitempty"`
+	Options []Option          `json:"options,omitempty"`
+}
+
+// OptionsFile returns the location of the user's additions and corrections
+// to the bundled option table.
+func OptionsFile() string {
+	return filepath.Join(ConfigDir, "options.json")
+}
+
+var (
+	optionsMu sync.Mutex
+	options   *OptionTable
+)
+
+// Options returns the bundled option table with OptionsFile merged over
+// it, loaded on first use.
+func Options() *OptionTable {
+	optionsMu.Lock()
+	defer optionsMu.Unlock()
+
+	if options == nil {
+		var err error
+		if options, err = LoadOptionTable(OptionsFile()); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+	return options
+}
+
+// LoadOptionTable returns the bundled table with the one in overrides
+// merged over it. A missing overrides file is not an error; an invalid one
+// leaves the bundled table.
+func LoadOptionTable(overrides string) (*OptionTable, error) {
+	var table OptionTable
+	if err := json.Unmarshal(bundledOptions, &table); err != nil {
+		panic(fmt.Sprintf("invalid bundled option table: %v", err))
+	}
+
+	data, err := os.ReadFile(overrides)
+	if os.IsNotExist(err) {
+		return &table, nil
+	}
+	if err != nil {
+		return &table, err
+	}
+
+	var user OptionTable
+	if err := json.Unmarshal(data, &user); err != nil {
+		return &table, fmt.Errorf("invalid option table %s: %w", overrides, err)
+	}
+	table.Merge(user)
+	return &table, nil
+}
+
+// Merge adds the models and options of update, replacing known ones.
+func (t *OptionTable) Merge(update OptionTable) {
+	if t.Models == nil {
+		t.Models = make(map[string]string)
+	}
+	for code, name := range update.Models {
+		t.Models[strings.ToLower(code)] = name
+	}
+
+	for _, option := range update.Options {
+		option.Code = strings.ToUpper(option.Code)
+		if option.Category == "" {
+			option.Category = OptionOther
+		}
+		i := slices.IndexFunc(t.Options, func(o Option) bool { return o.Code == option.Code })
+		if i < 0 {
+			t.Options = append(t.Options, option)
+		} else {
+			t.Options[i] = option
+		}
+	}
+
+	if update.Version > t.Version {
+		t.Version = update.Version
+	}
+}
+
+// Lookup returns the option with the given code. Unknown codes are returned
+// in OptionOther without a name.
+func (t *OptionTable) Lookup(code string) (Option, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if t != nil {
+		for _, option := range t.Options {
+			if option.Code == code {
+				return option, true
+			}
+		}
+	}
+	return Option{Code: code, Category: OptionOther}, false
+}
+
+// ModelName returns the name of a model code such as "my", or the code
+// itself if it is unknown.
+func (t *OptionTable) ModelName(code string) string {
+	if t != nil {
+		if name, ok := t.Models[strings.ToLower(code)]; ok {
+			return name
+		}
+	}
+	return code
+}
+
+// Configuration is the decoded model and option codes of an order.
+type Configuration struct {
+	ModelCode string
+	Model     string
+	Options   []Option
+}
+
+// Decode decodes the model code and the comma-separated option codes Tesla
+// returns as mktOptions.
+func (t *OptionTable) Decode(order Order) Configuration {
+	config := Configuration{ModelCode: order.ModelCode, Model: t.ModelName(order.ModelCode)}
+	for _, code := range strings.Split(order.MktOptions, ",") {
+		if strings.TrimSpace(code) == "" {
+			continue
+		}
+		option, _ := t.Lookup(code)
+		config.Options = append(config.Options, option)
+	}
+	return config
+}
+
+// ByCategory returns the options of the configuration in category.
+func (c Configuration) ByCategory(category OptionCategory) []Option {
+	var options []Option
+	for _, option := range c.Options {
+		if option.Category == category {
+			options = append(options, option)
+		}
+	}
+	return options
+}
@@ -0,0 +1,107 @@
+package tesla
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBundledOptions(t *testing.T) {
+	table, err := LoadOptionTable(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := table.ModelName("my"); got != "Model Y" {
+		t.Errorf("ModelName(my) = %q", got)
+	}
+	if got := table.ModelName("zz"); got != "zz" {
+		t.Errorf("ModelName(zz) = %q, want the code", got)
+	}
+
+	seen := make(map[string]bool)
+	for _, option := range table.Options {
+		if seen[option.Code] {
+			t.Errorf("option %s listed twice", option.Code)
+		}
+		seen[option.Code] = true
+		if option.Name == "" {
+			t.Errorf("option %s has no name", option.Code)
+		}
+		if !optionCategorySet[option.Category] {
+			t.Errorf("option %s has unknown category %q", option.Code, option.Category)
+		}
+	}
+}
+
+var optionCategorySet = func() map[OptionCategory]bool {
+	set := make(map[OptionCategory]bool)
+	for _, category := range OptionCategories {
+		set[category] = true
+	}
+	return set
+}()
+
+func TestDecodeConfiguration(t *testing.T) {
+	table := &OptionTable{
+		Models: map[string]string{"my": "Model Y"},
+		Options: []Option{
+			{Code: "PPSW", Category: OptionPaint, Name: "Pearl White Multi-Coat"},
+			{Code: "WY19B", Category: OptionWheels, Name: "19\" Gemini Wheels"},
+		},
+	}
+
+	config := table.Decode(Order{ModelCode: "my", MktOptions: "PPSW, wy19b,,XX99"})
+	if config.Model != "Model Y" || config.ModelCode != "my" {
+		t.Errorf("model = %q (%q)", config.Model, config.ModelCode)
+	}
+
+	want := []Option{
+		{Code: "PPSW", Category: OptionPaint, Name: "Pearl White Multi-Coat"},
+		{Code: "WY19B", Category: OptionWheels, Name: "19\" Gemini Wheels"},
+		{Code: "XX99", Category: OptionOther},
+	}
+	if !reflect.DeepEqual(config.Options, want) {
+		t.Errorf("Options = %+v, want %+v", config.Options, want)
+	}
+	if paint := config.ByCategory(OptionPaint); len(paint) != 1 || paint[0].Label() != "Pearl White Multi-Coat (PPSW)" {
+		t.Errorf("ByCategory(paint) = %+v", paint)
+	}
+	if got := want[2].Label(); got != "XX99" {
+		t.Errorf("Label() of an unknown code = %q", got)
+	}
+
+	if config := table.Decode(Order{ModelCode: "my"}); config.Options != nil {
+		t.Errorf("Decode() without option codes = %+v", config.Options)
+	}
+}
+
+func TestOptionTableOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "options.json")
+	data := `{"models": {"MY": "Model Y Juniper"}, "options": [{"code": "ppsw", "category": "paint", "name": "Pearl White"}, {"code": "NEW1", "name": "Something new"}]}`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	table, err := LoadOptionTable(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := table.ModelName("my"); got != "Model Y Juniper" {
+		t.Errorf("ModelName(my) = %q", got)
+	}
+	if option, _ := table.Lookup("PPSW"); option.Name != "Pearl White" {
+		t.Errorf("Lookup(PPSW) = %+v, want the override", option)
+	}
+	if option, ok := table.Lookup("NEW1"); !ok || option.Category != OptionOther {
+		t.Errorf("Lookup(NEW1) = %+v, %v", option, ok)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if table, err := LoadOptionTable(path); err == nil || table.ModelName("my") != "Model Y" {
+		t.Errorf("invalid overrides: err = %v, want the bundled table and an error", err)
+	}
+}
@@ -1,10 +1,19 @@
 package tesla
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
 	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
 )
 
 type Order struct {
@@ -12,6 +21,11 @@ type Order struct {
 	OrderStatus     string `json:"orderStatus"`
 	ModelCode       string `json:"modelCode"`
 	VIN             string `json:"vin,omitempty"`
+	// MktOptions is the comma-separated list of option codes of the
+	// configuration, such as "PPSW,IPB1,W38B".
+	MktOptions      string `json:"mktOptions,omitempty"`
+	CountryCode     string `json:"countryCode,omitempty"`
+	Locale          string `json:"locale,omitempty"`
 }
 
 type OrderDetails struct {
@@ -21,22 +35,85 @@ type OrderDetails struct {
 type DetailedOrder struct {
 	Order   Order        `json:"order"`
 	Details OrderDetails `json:"details"`
+	
+	// Stale is set when the details could not be refreshed and are the ones
+	// of the saved snapshot, if any.
+	Stale bool `json:"stale,omitempty"`
+}
+
+// DetailsConcurrency is the number of order details fetched at the same time.
+const DetailsConcurrency = 4
+
+// OrderError reports that the details of a single order could not be
+// fetched.
+type OrderError struct {
+	ReferenceNumber string
+	Err             error
+}
+
+func (e *OrderError) Error() string {
+	return fmt.Sprintf("order %s: %v", e.ReferenceNumber, e.Err)
+}
+
+func (e *OrderError) Unwrap() error {
+	return e.Err
 }
 
 type OrderManager struct {
-	Auth *TeslaAuth
+	Auth        *TeslaAuth
+	OrdersFile  string
+	HistoryFile string
+	
+	// Market overrides the market detected from the orders. Empty fields
+	// are detected.
+	Market Market
+	
+	stagesMu sync.Mutex
+	stages   map[string]*stageRecord
 }
 
 func NewOrderManager(auth *TeslaAuth) *OrderManager {
 	return &OrderManager{
-		Auth: auth,
+		Auth:        auth,
+		OrdersFile:  OrdersFile,
+		HistoryFile: HistoryFile,
 	}
 }
 
-func (m *OrderManager) RetrieveOrders() ([]Order, error) {
+// authorizedGet performs a GET request with the current access token,
+// refreshing it before the request when it is about to expire and once more
+// if the API answers 401.
+func (m *OrderManager) authorizedGet(ctx context.Context, requestURL string) (*resty.Response, error) {
+	if err := m.Auth.EnsureValidToken(ctx); err != nil {
+		return nil, err
+	}
+	
+	token := m.Auth.currentAccessToken()
 	resp, err := m.Auth.Client.R().
-		SetHeader("Authorization", fmt.Sprintf("Bearer %s", m.Auth.AccessToken)).
-		Get("https://owner-api.teslamotors.com/api/1/users/orders")
+		SetContext(ctx).
+		SetHeader("Authorization", fmt.Sprintf("Bearer %s", token)).
+		Get(requestURL)
+	if err != nil || resp.StatusCode() != http.StatusUnauthorized {
+		return resp, err
+	}
+	
+	if err := m.Auth.RefreshAfterUnauthorized(ctx, token); err != nil {
+		return nil, err
+	}
+	
+	resp, err = m.Auth.Client.R().
+		SetContext(ctx).
+		SetHeader("Authorization", fmt.Sprintf("Bearer %s", m.Auth.currentAccessToken())).
+		Get(requestURL)
+	if err == nil && resp.StatusCode() == http.StatusUnauthorized {
+		return nil, fmt.Errorf("%w: access token rejected after refresh", ErrLoginRequired)
+	}
+	
+	return resp, err
+}
+
+func (m *OrderManager) RetrieveOrders(ctx context.Context) ([]Order, error) {
+	resp, err := m.authorizedGet(ctx, m.Auth.Endpoints.OrdersURL)
 	
 	if err != nil {
 		return nil, err
@@ -57,11 +134,23 @@ func (m *OrderManager) RetrieveOrders() ([]Order, error) {
 	return response.Response, nil
 }
 
-func (m *OrderManager) GetOrderDetails(orderID string) (*OrderDetails, error) {
-	resp, err := m.Auth.Client.R().
-		SetHeader("Authorization", fmt.Sprintf("Bearer %s", m.Auth.AccessToken)).
-		Get(fmt.Sprintf("https://akamai-apigateway-vfx.tesla.com/tasks?deviceLanguage=en&deviceCountry=DE&referenceNumber=%s&appVersion=%s", 
-			orderID, AppVersion))
+// MarketFor returns the market used for the tasks request of order: the
+// configured market, completed by the one detected from the order and
+// finally by DefaultMarket.
+func (m *OrderManager) MarketFor(order DetailedOrder) Market {
+	return m.Market.Or(DetectMarket(order)).Or(DefaultMarket)
+}
+
+func (m *OrderManager) GetOrderDetails(ctx context.Context, orderID string, market Market) (*OrderDetails, error) {
+	market = market.Or(DefaultMarket)
+	
+	params := url.Values{}
+	params.Set("deviceLanguage", market.Language)
+	params.Set("deviceCountry", market.Country)
+	params.Set("referenceNumber", orderID)
+	params.Set("appVersion", AppVersion)
+	
+	resp, err := m.authorizedGet(ctx, fmt.Sprintf("%s?%s", m.Auth.Endpoints.TasksURL, params.Encode()))
 	
 	if err != nil {
 		return nil, err
@@ -79,46 +168,90 @@ func (m *OrderManager) GetOrderDetails(orderID string) (*OrderDetails, error) {
 	return &details, nil
 }
 
-func (m *OrderManager) GetDetailedOrders() ([]DetailedOrder, error) {
-	orders, err := m.RetrieveOrders()
+// GetDetailedOrders fetches the orders and their details. Details are
+// fetched concurrently; orders whose details fail keep the saved details and
+// are marked Stale, and the failures are returned as joined *OrderError
+// values alongside the orders. A cancelled ctx returns only its error.
+func (m *OrderManager) GetDetailedOrders(ctx context.Context) ([]DetailedOrder, error) {
+	orders, err := m.RetrieveOrders(ctx)
 	if err != nil {
 		return nil, err
 	}
 	
-	detailedOrders := make([]DetailedOrder, 0, len(orders))
-	
-	for _, order := range orders {
-		details, err := m.GetOrderDetails(order.ReferenceNumber)
-		if err != nil {
-			return nil, err
+	cached := map[string]DetailedOrder{}
+	if previous, err := m.LoadOrdersFromFile(); err == nil {
+		for _, order := range previous {
+			cached[order.Order.ReferenceNumber] = order
 		}
-		
-		detailedOrder := DetailedOrder{
-			Order:   order,
-			Details: *details,
-		}
-		
-		detailedOrders = append(detailedOrders, detailedOrder)
 	}
 	
-	return detailedOrders, nil
+	detailedOrders := make([]DetailedOrder, len(orders))
+	errs := make([]error, len(orders))
+	
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, DetailsConcurrency)
+	
+	for i, order := range orders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+			
+			// The saved details reveal the market of orders whose summary
+			// does not.
+			previous := cached[order.ReferenceNumber]
+			market := m.MarketFor(DetailedOrder{Order: order, Details: previous.Details})
+			
+			details, err := m.GetOrderDetails(ctx, order.ReferenceNumber, market)
+			if err != nil {
+				errs[i] = &OrderError{ReferenceNumber: order.ReferenceNumber, Err: err}
+				detailedOrders[i] = DetailedOrder{Order: order, Details: previous.Details, Stale: true}
+				return
+			}
+			
+			detailedOrders[i] = DetailedOrder{Order: order, Details: *details}
+		}()
+	}
+	wg.Wait()
+	
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	
+	return detailedOrders, errors.Join(errs...)
 }
 
+// SaveOrdersToFile replaces the saved snapshot with orders, first appending
+// the timeline changes since the previous snapshot to the history file.
 func (m *OrderManager) SaveOrdersToFile(orders []DetailedOrder) error {
 	data, err := json.Marshal(orders)
 	if err != nil {
 		return err
 	}
 	
-	return os.WriteFile(OrdersFile, data, 0600)
+	previous, err := m.LoadOrdersFromFile()
+	if err != nil {
+		previous = nil
+	}
+	
+	if err := m.AppendHistory(m.HistoryEntries(previous, orders, time.Now())); err != nil {
+		return err
+	}
+	
+	return os.WriteFile(m.OrdersFile, data, 0600)
 }
 
 func (m *OrderManager) LoadOrdersFromFile() ([]DetailedOrder, error) {
-	if _, err := os.Stat(OrdersFile); os.IsNotExist(err) {
+	if _, err := os.Stat(m.OrdersFile); os.IsNotExist(err) {
 		return nil, nil
 	}
 	
-	data, err := os.ReadFile(OrdersFile)
+	data, err := os.ReadFile(m.OrdersFile)
 	if err != nil {
 		return nil, err
 	}
@@ -131,23 +264,51 @@ func (m *OrderManager) LoadOrdersFromFile() ([]DetailedOrder, error) {
 	return orders, nil
 }
 
-func (m *OrderManager) CompareOrders(old, new []DetailedOrder) []string {
-	differences := []string{}
+// CompareOrders pairs the orders of both snapshots by reference number and
+// returns every difference between them.
+func (m *OrderManager) CompareOrders(old, new []DetailedOrder) []Change {
+	changes := []Change{}
 	
-	for i, oldOrder := range old {
-		if i < len(new) {
-			diff := compareMaps(extractMap(oldOrder), extractMap(new[i]), "")
-			differences = append(differences, diff...)
+	newByReference := make(map[string]DetailedOrder, len(new))
+	for _, order := range new {
+		newByReference[order.Order.ReferenceNumber] = order
+	}
+	
+	oldReferences := make(map[string]bool, len(old))
+	for _, oldOrder := range old {
+		referenceNumber := oldOrder.Order.ReferenceNumber
+		oldReferences[referenceNumber] = true
+		
+		if newOrder, ok := newByReference[referenceNumber]; ok {
+			oldMap, newMap := extractMap(oldOrder), extractMap(newOrder)
+			// Details that were not refreshed, or never fetched, tell
+			// nothing about changes.
+			if newOrder.Stale || oldOrder.Details.Tasks == nil {
+				delete(oldMap, "details")
+				delete(newMap, "details")
+			}
+			delete(oldMap, "stale")
+			delete(newMap, "stale")
+			diff := compareMaps(referenceNumber, oldMap, newMap, "")
+			changes = append(changes, diff...)
 		} else {
-			differences = append(differences, fmt.Sprintf("Removed order %s", oldOrder.Order.ReferenceNumber))
+			changes = append(changes, Change{
+				ReferenceNumber: referenceNumber,
+				Kind:            ChangeRemoved,
+			})
 		}
 	}
 	
-	for i := len(old); i < len(new); i++ {
-		differences = append(differences, fmt.Sprintf("Added order %s", new[i].Order.ReferenceNumber))
+	for _, newOrder := range new {
+		if !oldReferences[newOrder.Order.ReferenceNumber] {
+			changes = append(changes, Change{
+				ReferenceNumber: newOrder.Order.ReferenceNumber,
+				Kind:            ChangeAdded,
+			})
+		}
 	}
 	
-	return differences
+	return changes
 }
 
 func extractMap(order DetailedOrder) map[string]interface{} {
@@ -157,32 +318,108 @@ func extractMap(order DetailedOrder) map[string]interface{} {
 	return result
 }
 
-func compareMaps(old, new map[string]interface{}, path string) []string {
-	differences := []string{}
+func compareMaps(referenceNumber string, old, new map[string]interface{}, path string) []Change {
+	changes := []Change{}
 	
-	for key, oldValue := range old {
+	for _, key := range sortedKeys(old) {
+		keyPath := joinPath(path, key)
 		if newValue, exists := new[key]; !exists {
-			differences = append(differences, fmt.Sprintf("Removed key '%s%s'", path, key))
+			changes = append(changes, Change{
+				ReferenceNumber: referenceNumber,
+				Path:            keyPath,
+				Kind:            ChangeRemoved,
+				OldValue:        old[key],
+			})
 		} else {
-			oldMap, oldIsMap := oldValue.(map[string]interface{})
-			newMap, newIsMap := newValue.(map[string]interface{})
-			
-			if oldIsMap && newIsMap {
-				diff := compareMaps(oldMap, newMap, fmt.Sprintf("%s%s.", path, key))
-				differences = append(differences, diff...)
-			} else if !areValuesEqual(oldValue, newValue) {
-				differences = append(differences, fmt.Sprintf("Changed value at '%s%s': %v -> %v", path, key, formatValue(oldValue), formatValue(newValue)))
-			}
+			changes = append(changes, compareValues(referenceNumber, old[key], newValue, keyPath)...)
 		}
 	}
 	
-	for key, newValue := range new {
+	for _, key := range sortedKeys(new) {
 		if _, exists := old[key]; !exists {
-			differences = append(differences, fmt.Sprintf("Added key '%s%s': %v", path, key, formatValue(newValue)))
+			changes = append(changes, Change{
+				ReferenceNumber: referenceNumber,
+				Path:            joinPath(path, key),
+				Kind:            ChangeAdded,
+				NewValue:        new[key],
+			})
 		}
 	}
 	
-	return differences
+	return changes
+}
+
+// compareValues descends into nested maps and arrays so that a change is
+// reported at the deepest path where the values differ.
+func compareValues(referenceNumber string, oldValue, newValue interface{}, path string) []Change {
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		return compareMaps(referenceNumber, oldMap, newMap, path)
+	}
+	
+	oldSlice, oldIsSlice := oldValue.([]interface{})
+	newSlice, newIsSlice := newValue.([]interface{})
+	if oldIsSlice && newIsSlice {
+		return compareSlices(referenceNumber, oldSlice, newSlice, path)
+	}
+	
+	if areValuesEqual(oldValue, newValue) {
+		return nil
+	}
+	
+	return []Change{{
+		ReferenceNumber: referenceNumber,
+		Path:            path,
+		Kind:            ChangeChanged,
+		OldValue:        oldValue,
+		NewValue:        newValue,
+	}}
+}
+
+func compareSlices(referenceNumber string, old, new []interface{}, path string) []Change {
+	changes := []Change{}
+	
+	for i, oldValue := range old {
+		indexPath := fmt.Sprintf("%s[%d]", path, i)
+		if i < len(new) {
+			changes = append(changes, compareValues(referenceNumber, oldValue, new[i], indexPath)...)
+		} else {
+			changes = append(changes, Change{
+				ReferenceNumber: referenceNumber,
+				Path:            indexPath,
+				Kind:            ChangeRemoved,
+				OldValue:        oldValue,
+			})
+		}
+	}
+	
+	for i := len(old); i < len(new); i++ {
+		changes = append(changes, Change{
+			ReferenceNumber: referenceNumber,
+			Path:            fmt.Sprintf("%s[%d]", path, i),
+			Kind:            ChangeAdded,
+			NewValue:        new[i],
+		})
+	}
+	
+	return changes
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
 }
 
 func areValuesEqual(a, b interface{}) bool {
@@ -193,37 +430,11 @@ func areValuesEqual(a, b interface{}) bool {
 		return false
 	}
 
-	aType := reflect.TypeOf(a)
-	bType := reflect.TypeOf(b)
-
-	if aType != bType {
+	if reflect.TypeOf(a) != reflect.TypeOf(b) {
 		return false
 	}
 
-	switch aVal := a.(type) {
-	case map[string]interface{}:
-		bVal, ok := b.(map[string]interface{})
-		if !ok {
-			return false
-		}
-		if len(aVal) == 0 && len(bVal) == 0 {
-			return true
-		}
-		return len(aVal) == len(bVal)
-
-	case []interface{}:
-		bVal, ok := b.([]interface{})
-		if !ok {
-			return false
-		}
-		if len(aVal) == 0 && len(bVal) == 0 {
-			return true
-		}
-		return len(aVal) == len(bVal)
-
-	default:
-		return reflect.DeepEqual(a, b)
-	}
+	return reflect.DeepEqual(a, b)
 }
 
 func formatValue(v interface{}) string {
@@ -248,45 +459,59 @@ func (m *OrderManager) ExtractOrderInfo(order DetailedOrder) map[string]string {
 	info["Status"] = order.Order.OrderStatus
 	info["Model"] = order.Order.ModelCode
 	info["VIN"] = order.Order.VIN
+	if order.Order.MktOptions != "" {
+		info["Options"] = order.Order.MktOptions
+	}
 	
-	tasks := order.Details.Tasks
+	tasks := order.Details.TypedTasks()
 	
-	if registration, ok := tasks["registration"].(map[string]interface{}); ok {
-		if orderDetails, ok := registration["orderDetails"].(map[string]interface{}); ok {
-			setIfString(orderDetails, "reservationDate", &info, "ReservationDate")
-			setIfString(orderDetails, "orderBookedDate", &info, "OrderBookedDate")
-			setIfString(orderDetails, "vehicleOdometer", &info, "VehicleOdometer")
-			setIfString(orderDetails, "vehicleOdometerType", &info, "VehicleOdometerType")
-			setIfString(orderDetails, "vehicleRoutingLocation", &info, "VehicleRoutingLocation")
-		}
+	if registration := tasks.Registration; registration != nil && registration.OrderDetails != nil {
+		details := registration.OrderDetails
+		setString(info, "ReservationDate", details.ReservationDate)
+		setString(info, "OrderBookedDate", details.OrderBookedDate)
+		setNumber(info, "VehicleOdometer", details.VehicleOdometer, "%v")
+		setString(info, "VehicleOdometerType", details.VehicleOdometerType)
+		setNumber(info, "VehicleRoutingLocation", details.VehicleRoutingLocation, "%.2f")
+	}
+	
+	if scheduling := tasks.Scheduling; scheduling != nil {
+		setString(info, "DeliveryWindow", scheduling.DeliveryWindowDisplay)
+		setString(info, "DeliveryAppointment", scheduling.ApptDateTimeAddressStr)
 	}
 	
-	if scheduling, ok := tasks["scheduling"].(map[string]interface{}); ok {
-		setIfString(scheduling, "deliveryWindowDisplay", &info, "DeliveryWindow")
-		setIfString(scheduling, "apptDateTimeAddressStr", &info, "DeliveryAppointment")
+	if finalPayment := tasks.FinalPayment; finalPayment != nil && finalPayment.Data != nil {
+		setString(info, "ETAToDeliveryCenter", finalPayment.Data.ETAToDeliveryCenter)
 	}
 	
-	if finalPayment, ok := tasks["finalPayment"].(map[string]interface{}); ok {
-		if data, ok := finalPayment["data"].(map[string]interface{}); ok {
-			setIfString(data, "etaToDeliveryCenter", &info, "ETAToDeliveryCenter")
+	if finalPayment := tasks.FinalPayment; finalPayment != nil {
+		if amountDue := finalPayment.amountDue(); amountDue.Valid {
+			info["AmountDue"] = fmt.Sprintf("%.2f", amountDue.Value)
 		}
 	}
 	
 	return info
 }
 
-func setIfString(data map[string]interface{}, key string, info *map[string]string, infoKey string) {
-	if v, ok := data[key]; ok {
-		if s, ok := v.(string); ok && s != "" {
-			(*info)[infoKey] = s
-		} else if f, ok := v.(float64); ok {
-			if key == "vehicleOdometer" {
-				(*info)[infoKey] = fmt.Sprintf("%v", f)
-			} else {
-				(*info)[infoKey] = fmt.Sprintf("%.2f", f)
-			}
-		}
-	} else {
-		(*info)[infoKey] = "N/A"
+// setString stores a field sent by Tesla in info. Fields missing from the
+// task become "N/A"; null and empty ones are left out.
+func setString(info map[string]string, key string, field Field[string]) {
+	switch {
+	case !field.Present:
+		info[key] = "N/A"
+	case field.Valid && field.Value != "":
+		info[key] = field.Value
+	}
+}
+
+// setNumber is setString for numeric fields, formatted with format. A value
+// Tesla sent as a string is shown as sent.
+func setNumber(info map[string]string, key string, field Field[float64], format string) {
+	switch {
+	case !field.Present:
+		info[key] = "N/A"
+	case field.Text != "":
+		info[key] = field.Text
+	case field.Valid:
+		info[key] = fmt.Sprintf(format, field.Value)
 	}
-} 
\ No newline at end of file
+}
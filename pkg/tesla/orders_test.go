@@ -0,0 +1,250 @@
+package tesla
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func testOrder(referenceNumber, status string, tasks map[string]interface{}) DetailedOrder {
+	return DetailedOrder{
+		Order:   Order{ReferenceNumber: referenceNumber, OrderStatus: status, ModelCode: "my"},
+		Details: OrderDetails{Tasks: tasks},
+	}
+}
+
+func TestCompareOrders(t *testing.T) {
+	m := &OrderManager{}
+
+	tests := []struct {
+		name string
+		old  []DetailedOrder
+		new  []DetailedOrder
+		want []Change
+	}{
+		{
+			name: "unchanged",
+			old:  []DetailedOrder{testOrder("RN1", "BOOKED", nil)},
+			new:  []DetailedOrder{testOrder("RN1", "BOOKED", nil)},
+			want: []Change{},
+		},
+		{
+			name: "reordered",
+			old:  []DetailedOrder{testOrder("RN1", "BOOKED", nil), testOrder("RN2", "BOOKED", nil)},
+			new:  []DetailedOrder{testOrder("RN2", "BOOKED", nil), testOrder("RN1", "BOOKED", nil)},
+			want: []Change{},
+		},
+		{
+			name: "status changed",
+			old:  []DetailedOrder{testOrder("RN1", "BOOKED", nil)},
+			new:  []DetailedOrder{testOrder("RN1", "DELIVERED", nil)},
+			want: []Change{{
+				ReferenceNumber: "RN1",
+				Path:            "order.orderStatus",
+				Kind:            ChangeChanged,
+				OldValue:        "BOOKED",
+				NewValue:        "DELIVERED",
+			}},
+		},
+		{
+			name: "order added and removed",
+			old:  []DetailedOrder{testOrder("RN1", "BOOKED", nil)},
+			new:  []DetailedOrder{testOrder("RN2", "BOOKED", nil)},
+			want: []Change{
+				{ReferenceNumber: "RN1", Kind: ChangeRemoved},
+				{ReferenceNumber: "RN2", Kind: ChangeAdded},
+			},
+		},
+		{
+			name: "array element changed",
+			old: []DetailedOrder{testOrder("RN1", "BOOKED", map[string]interface{}{
+				"registration": map[string]interface{}{"names": []interface{}{"a", "b"}},
+			})},
+			new: []DetailedOrder{testOrder("RN1", "BOOKED", map[string]interface{}{
+				"registration": map[string]interface{}{"names": []interface{}{"a", "c"}},
+			})},
+			want: []Change{{
+				ReferenceNumber: "RN1",
+				Path:            "details.tasks.registration.names[1]",
+				Kind:            ChangeChanged,
+				OldValue:        "b",
+				NewValue:        "c",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.CompareOrders(tt.old, tt.new)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompareOrders() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareMaps(t *testing.T) {
+	tests := []struct {
+		name string
+		old  map[string]interface{}
+		new  map[string]interface{}
+		want []Change
+	}{
+		{
+			name: "equal",
+			old:  map[string]interface{}{"a": 1.0, "b": "x"},
+			new:  map[string]interface{}{"a": 1.0, "b": "x"},
+			want: []Change{},
+		},
+		{
+			name: "key added and removed",
+			old:  map[string]interface{}{"a": 1.0},
+			new:  map[string]interface{}{"b": 2.0},
+			want: []Change{
+				{ReferenceNumber: "RN1", Path: "root.a", Kind: ChangeRemoved, OldValue: 1.0},
+				{ReferenceNumber: "RN1", Path: "root.b", Kind: ChangeAdded, NewValue: 2.0},
+			},
+		},
+		{
+			name: "type changed",
+			old:  map[string]interface{}{"a": "1"},
+			new:  map[string]interface{}{"a": 1.0},
+			want: []Change{
+				{ReferenceNumber: "RN1", Path: "root.a", Kind: ChangeChanged, OldValue: "1", NewValue: 1.0},
+			},
+		},
+		{
+			name: "nested value changed",
+			old:  map[string]interface{}{"a": map[string]interface{}{"b": "x", "c": "y"}},
+			new:  map[string]interface{}{"a": map[string]interface{}{"b": "x", "c": "z"}},
+			want: []Change{
+				{ReferenceNumber: "RN1", Path: "root.a.c", Kind: ChangeChanged, OldValue: "y", NewValue: "z"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := compareMaps("RN1", tt.old, tt.new, "root")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compareMaps() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractOrderInfo(t *testing.T) {
+	m := &OrderManager{}
+	order := testOrder("RN1", "BOOKED", map[string]interface{}{
+		"registration": map[string]interface{}{
+			"orderDetails": map[string]interface{}{
+				"reservationDate":        "2025-01-01",
+				"orderBookedDate":        "2025-01-02",
+				"vehicleOdometer":        12.0,
+				"vehicleOdometerType":    "KM",
+				"vehicleRoutingLocation": 2614.0,
+			},
+		},
+		"scheduling": map[string]interface{}{
+			"deliveryWindowDisplay": "Mar 1 - Mar 15",
+		},
+		"finalPayment": map[string]interface{}{
+			"data": map[string]interface{}{"etaToDeliveryCenter": "Mar 3"},
+		},
+	})
+	order.Order.VIN = "VIN1"
+
+	want := map[string]string{
+		"OrderID":                "RN1",
+		"Status":                 "BOOKED",
+		"Model":                  "my",
+		"VIN":                    "VIN1",
+		"ReservationDate":        "2025-01-01",
+		"OrderBookedDate":        "2025-01-02",
+		"VehicleOdometer":        "12",
+		"VehicleOdometerType":    "KM",
+		"VehicleRoutingLocation": "2614.00",
+		"DeliveryWindow":         "Mar 1 - Mar 15",
+		"DeliveryAppointment":    "N/A",
+		"ETAToDeliveryCenter":    "Mar 3",
+	}
+
+	if got := m.ExtractOrderInfo(order); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractOrderInfo() = %v, want %v", got, want)
+	}
+}
+
+// TestExtractOrderInfoStringValues covers the values Tesla has sent as
+// strings where numbers are documented, and the other way round.
+func TestExtractOrderInfoStringValues(t *testing.T) {
+	m := &OrderManager{}
+	order := testOrder("RN1", "BOOKED", map[string]interface{}{
+		"registration": map[string]interface{}{
+			"orderDetails": map[string]interface{}{
+				"reservationDate":        20250110.0,
+				"vehicleOdometer":        "30",
+				"vehicleOdometerType":    "KM",
+				"vehicleRoutingLocation": "2614",
+			},
+		},
+		"scheduling": map[string]interface{}{
+			"deliveryWindowDisplay": "",
+		},
+	})
+
+	info := m.ExtractOrderInfo(order)
+	for key, want := range map[string]string{
+		"ReservationDate":        "20250110",
+		"VehicleOdometer":        "30",
+		"VehicleRoutingLocation": "2614",
+		"OrderBookedDate":        "N/A",
+	} {
+		if got := info[key]; got != want {
+			t.Errorf("info[%s] = %q, want %q", key, got, want)
+		}
+	}
+	if got, ok := info["DeliveryWindow"]; ok {
+		t.Errorf("empty DeliveryWindow = %q, want it left out", got)
+	}
+	if got := RoutingLocation(order); got != 2614 {
+		t.Errorf("RoutingLocation() = %d, want 2614", got)
+	}
+}
+
+func TestSetNumber(t *testing.T) {
+	tests := []struct {
+		name   string
+		json   string
+		format string
+		want   string
+		wantOK bool
+	}{
+		{"float", `{"k":1.5}`, "%.2f", "1.50", true},
+		{"odometer", `{"k":1.5}`, "%v", "1.5", true},
+		{"numeric string", `{"k":"12"}`, "%.2f", "12", true},
+		{"other string", `{"k":"Istanbul"}`, "%.2f", "Istanbul", true},
+		{"missing", `{}`, "%v", "N/A", true},
+		{"null", `{"k":null}`, "%v", "", false},
+		{"empty string", `{"k":""}`, "%v", "", false},
+		{"object", `{"k":{}}`, "%v", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data struct {
+				K Field[float64] `json:"k"`
+			}
+			if err := json.Unmarshal([]byte(tt.json), &data); err != nil {
+				t.Fatal(err)
+			}
+
+			info := map[string]string{}
+			setNumber(info, "Field", data.K, tt.format)
+
+			got, ok := info["Field"]
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("setNumber() = %q (set %v), want %q (set %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
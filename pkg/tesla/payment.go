@@ -0,0 +1,246 @@
+package tesla
+
+import (
+	"math"
+	"slices"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// countryCurrencies is the currency assumed when the order payload does not
+// name one.
+var countryCurrencies = map[string]string{
+	"AT": "EUR", "BE": "EUR", "DE": "EUR", "EE": "EUR", "ES": "EUR", "FI": "EUR",
+	"FR": "EUR", "GR": "EUR", "IE": "EUR", "IT": "EUR", "LT": "EUR", "LU": "EUR",
+	"LV": "EUR", "NL": "EUR", "PT": "EUR", "SI": "EUR", "SK": "EUR",
+	"AU": "AUD", "CA": "CAD", "CH": "CHF", "CN": "CNY", "CZ": "CZK", "DK": "DKK",
+	"GB": "GBP", "HK": "HKD", "HU": "HUF", "JP": "JPY", "KR": "KRW", "MX": "MXN",
+	"NO": "NOK", "NZ": "NZD", "PL": "PLN", "RO": "RON", "SE": "SEK", "TR": "TRY",
+	"TW": "TWD", "US": "USD",
+}
+
+// Deposit is a payment made towards the order after the reservation.
+type Deposit struct {
+	Type   string
+	Amount float64
+}
+
+// Incentive is a discount on the price, such as a government or referral
+// incentive. Amount is positive.
+type Incentive struct {
+	Description string
+	Amount      float64
+}
+
+// Payment is the money side of an order as far as the tasks payload tells.
+// Amounts the payload does not contain are zero.
+type Payment struct {
+	Currency    string
+	Reservation float64
+	Deposits    []Deposit
+	TradeIn     float64
+	Financed    float64
+	AmountDue   float64
+	Status      string
+
+	// The price breakdown, when Tesla sends one.
+	BasePrice      float64
+	OptionsPrice   float64
+	DestinationFee float64
+	OrderFee       float64
+	Incentives     []Incentive
+	// StatedTotal is the total price as Tesla states it.
+	StatedTotal float64
+}
+
+// Total is the order amount: everything paid, credited and financed plus
+// the amount still due.
+func (p Payment) Total() float64 {
+	total := p.Reservation + p.TradeIn + p.Financed + p.AmountDue
+	for _, deposit := range p.Deposits {
+		total += deposit.Amount
+	}
+	return total
+}
+
+// HasBreakdown reports whether any part of the price breakdown is known.
+func (p Payment) HasBreakdown() bool {
+	return p.BasePrice != 0 || p.OptionsPrice != 0 || p.DestinationFee != 0 || p.OrderFee != 0 || len(p.Incentives) > 0
+}
+
+// Price is the price according to the breakdown: base price, options and
+// fees less the incentives.
+func (p Payment) Price() float64 {
+	price := p.BasePrice + p.OptionsPrice + p.DestinationFee + p.OrderFee
+	for _, incentive := range p.Incentives {
+		price -= incentive.Amount
+	}
+	return price
+}
+
+// Reconciliation compares the amount due with the price less everything
+// already paid, credited or financed.
+type Reconciliation struct {
+	// Price is the breakdown price, or the stated total without a breakdown.
+	Price       float64
+	StatedTotal float64
+	Credited    float64
+	ExpectedDue float64
+	// Difference is the amount due minus the expected amount due.
+	Difference float64
+}
+
+// Balanced reports whether the amounts add up, to the cent.
+func (r Reconciliation) Balanced() bool {
+	return math.Abs(r.Difference) < 0.005 && (r.StatedTotal == 0 || math.Abs(r.StatedTotal-r.Price) < 0.005)
+}
+
+// Reconcile reconciles the payment against its price. It returns false when
+// Tesla sent neither a breakdown nor a total price.
+func (p Payment) Reconcile() (Reconciliation, bool) {
+	r := Reconciliation{StatedTotal: p.StatedTotal}
+	switch {
+	case p.HasBreakdown():
+		r.Price = p.Price()
+	case p.StatedTotal != 0:
+		r.Price = p.StatedTotal
+	default:
+		return r, false
+	}
+
+	r.Credited = p.Total() - p.AmountDue
+	r.ExpectedDue = r.Price - r.Credited
+	r.Difference = p.AmountDue - r.ExpectedDue
+	return r, true
+}
+
+// ExtractPayment collects the payment amounts of order. The currency comes
+// from the payload, or from the order's market when the payload has none.
+func (m *OrderManager) ExtractPayment(order DetailedOrder) Payment {
+	tasks := order.Details.TypedTasks()
+
+	var payment Payment
+	var currencies []Field[string]
+
+	if tasks.Registration != nil && tasks.Registration.OrderDetails != nil {
+		details := tasks.Registration.OrderDetails
+		payment.Reservation = firstNumber(details.ReservationAmountReceived)
+		currencies = append(currencies, details.CurrencyCode)
+	}
+
+	var tradeIns, financed []Field[float64]
+	if finalPayment := tasks.FinalPayment; finalPayment != nil {
+		payment.Status = finalPayment.Status
+		payment.AmountDue = finalPayment.amountDue().Value
+		currencies = append(currencies, finalPayment.CurrencyCode, finalPayment.CurrencyFormat.CurrencyCode)
+
+		if data := finalPayment.Data; data != nil {
+			currencies = append(currencies, data.CurrencyCode)
+			tradeIns = append(tradeIns, data.TradeInAmount, data.TradeInCredit)
+			financed = append(financed, data.LoanAmount, data.FinancedAmount)
+
+			for _, detail := range data.PaymentDetails {
+				if amount := firstNumber(detail.AmountPaid, detail.Amount); amount != 0 {
+					payment.Deposits = append(payment.Deposits, Deposit{Type: detail.PaymentType.Value, Amount: amount})
+				}
+			}
+
+			payment.BasePrice = firstNumber(data.BasePrice)
+			payment.OptionsPrice = firstNumber(data.OptionsPrice)
+			payment.DestinationFee = firstNumber(data.DestinationFee)
+			payment.OrderFee = firstNumber(data.OrderFee)
+			payment.StatedTotal = firstNumber(data.TotalPrice)
+			payment.Incentives = appendIncentives(payment.Incentives, data.Incentives)
+		}
+	}
+	if tasks.TradeIn != nil {
+		tradeIns = append(tradeIns, tasks.TradeIn.TradeInAmount)
+	}
+	if tasks.Financing != nil {
+		financed = append(financed, tasks.Financing.LoanAmount)
+		payment.Incentives = appendIncentives(payment.Incentives, tasks.Financing.Incentives)
+	}
+
+	payment.TradeIn = firstNumber(tradeIns...)
+	payment.Financed = firstNumber(financed...)
+	payment.Currency = firstString(currencies...)
+	if payment.Currency == "" {
+		payment.Currency = countryCurrencies[m.MarketFor(order).Country]
+	}
+
+	return payment
+}
+
+// amountDue returns the amount due of the task, preferring the top-level
+// field over the one in data. A valid 0 is kept: it is what a fully paid
+// order reports.
+func (t *FinalPaymentTask) amountDue() Field[float64] {
+	if !t.AmountDue.Valid && t.Data != nil {
+		return t.Data.AmountDue
+	}
+	return t.AmountDue
+}
+
+// appendIncentives adds the non-zero items, whatever sign Tesla gives them.
+// An incentive listed by both tasks is only counted once.
+func appendIncentives(incentives []Incentive, items []PricingItem) []Incentive {
+	for _, item := range items {
+		incentive := Incentive{Description: item.Description.Value, Amount: math.Abs(firstNumber(item.Amount))}
+		if incentive.Amount != 0 && !slices.Contains(incentives, incentive) {
+			incentives = append(incentives, incentive)
+		}
+	}
+	return incentives
+}
+
+// FormatAmount formats amount with the digit grouping, decimal separator,
+// number of decimals and currency symbol customary in market. English
+// markets put a currency symbol before the number; the others, and currency
+// codes without a symbol, go after it.
+func FormatAmount(amount float64, currencyCode string, market Market) string {
+	printer := message.NewPrinter(language.Make(market.Locale()))
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	unit, err := currency.ParseISO(currencyCode)
+	if err != nil {
+		formatted := printer.Sprint(number.Decimal(amount, number.Scale(2)))
+		if currencyCode == "" {
+			return sign + formatted
+		}
+		return sign + formatted + " " + currencyCode
+	}
+
+	scale, _ := currency.Standard.Rounding(unit)
+	formatted := printer.Sprint(number.Decimal(amount, number.Scale(scale)))
+	symbol := printer.Sprint(currency.NarrowSymbol(unit))
+	if market.Language == "en" && symbol != unit.String() {
+		return sign + symbol + formatted
+	}
+	return sign + formatted + " " + symbol
+}
+
+func firstString(fields ...Field[string]) string {
+	for _, field := range fields {
+		if field.Valid && field.Value != "" {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+func firstNumber(fields ...Field[float64]) float64 {
+	for _, field := range fields {
+		if field.Valid && field.Value != 0 {
+			return field.Value
+		}
+	}
+	return 0
+}
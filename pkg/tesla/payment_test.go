@@ -0,0 +1,198 @@
+package tesla
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestExtractPayment(t *testing.T) {
+	m := &OrderManager{}
+
+	order := DetailedOrder{Details: OrderDetails{Tasks: map[string]interface{}{
+		"registration": map[string]interface{}{
+			"orderDetails": map[string]interface{}{
+				"reservationAmountReceived": 250.0,
+				"currencyCode":              "EUR",
+			},
+		},
+		"finalPayment": map[string]interface{}{
+			"status":    "MAKE_YOUR_FINAL_PAYMENT",
+			"amountDue": 20000.0,
+			"data": map[string]interface{}{
+				"tradeInAmount": 8000.0,
+				"loanAmount":    15000.0,
+				"paymentDetails": []interface{}{
+					map[string]interface{}{"paymentType": "WIRE", "amountPaid": 1000.0},
+					map[string]interface{}{"paymentType": "CARD", "amountPaid": 0.0},
+				},
+			},
+		},
+	}}}
+
+	want := Payment{
+		Currency:    "EUR",
+		Reservation: 250,
+		Deposits:    []Deposit{{Type: "WIRE", Amount: 1000}},
+		TradeIn:     8000,
+		Financed:    15000,
+		AmountDue:   20000,
+		Status:      "MAKE_YOUR_FINAL_PAYMENT",
+	}
+
+	got := m.ExtractPayment(order)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ExtractPayment() = %+v, want %+v", got, want)
+	}
+	if total := got.Total(); total != 44250 {
+		t.Errorf("Total() = %v, want 44250", total)
+	}
+}
+
+func TestExtractPaymentAmountDue(t *testing.T) {
+	m := &OrderManager{}
+
+	tests := []struct {
+		name         string
+		finalPayment map[string]interface{}
+		want         float64
+	}{
+		{"fully paid", map[string]interface{}{"amountDue": 0.0, "data": map[string]interface{}{"amountDue": 500.0}}, 0},
+		{"only in data", map[string]interface{}{"data": map[string]interface{}{"amountDue": 500.0}}, 500},
+		{"both", map[string]interface{}{"amountDue": 300.0, "data": map[string]interface{}{"amountDue": 500.0}}, 300},
+	}
+	for _, tt := range tests {
+		order := testOrder("RN1", "BOOKED", map[string]interface{}{"finalPayment": tt.finalPayment})
+
+		if got := m.ExtractPayment(order).AmountDue; got != tt.want {
+			t.Errorf("%s: ExtractPayment().AmountDue = %v, want %v", tt.name, got, tt.want)
+		}
+		// The history and change highlighting must agree with the pricing tab.
+		if got, want := m.ExtractOrderInfo(order)["AmountDue"], fmt.Sprintf("%.2f", tt.want); got != want {
+			t.Errorf("%s: ExtractOrderInfo() AmountDue = %q, want %q", tt.name, got, want)
+		}
+	}
+}
+
+func TestExtractPaymentBreakdown(t *testing.T) {
+	m := &OrderManager{}
+
+	order := DetailedOrder{Details: OrderDetails{Tasks: map[string]interface{}{
+		"registration": map[string]interface{}{
+			"orderDetails": map[string]interface{}{"reservationAmountReceived": 250.0},
+		},
+		"finalPayment": map[string]interface{}{
+			"amountDue": 41740.0,
+			"data": map[string]interface{}{
+				"basePrice":      44990.0,
+				"optionsPrice":   2000.0,
+				"destinationFee": 1000.0,
+				"orderFee":       250.0,
+				"totalPrice":     45990.0,
+				"incentives": []interface{}{
+					map[string]interface{}{"description": "Referral", "amount": -2000.0},
+				},
+				"paymentDetails": []interface{}{
+					map[string]interface{}{"paymentType": "WIRE", "amountPaid": 4000.0},
+				},
+			},
+		},
+		"financing": map[string]interface{}{
+			"incentives": []interface{}{
+				map[string]interface{}{"description": "Referral", "amount": 2000.0},
+				map[string]interface{}{"description": "Zero", "amount": 0.0},
+			},
+		},
+	}}}
+
+	payment := m.ExtractPayment(order)
+	if payment.BasePrice != 44990 || payment.OptionsPrice != 2000 || payment.DestinationFee != 1000 || payment.OrderFee != 250 || payment.StatedTotal != 45990 {
+		t.Errorf("breakdown = %+v", payment)
+	}
+	if want := []Incentive{{Description: "Referral", Amount: 2000}}; !reflect.DeepEqual(payment.Incentives, want) {
+		t.Errorf("Incentives = %+v, want %+v", payment.Incentives, want)
+	}
+	if price := payment.Price(); price != 46240 {
+		t.Errorf("Price() = %v, want 46240", price)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	tests := []struct {
+		name       string
+		payment    Payment
+		ok         bool
+		balanced   bool
+		difference float64
+	}{
+		{"no price", Payment{AmountDue: 1000}, false, false, 0},
+		{"balanced", Payment{BasePrice: 40000, OrderFee: 250, Reservation: 250, TradeIn: 10000, AmountDue: 30000}, true, true, 0},
+		{"stated total only", Payment{StatedTotal: 40000, Reservation: 250, AmountDue: 39750}, true, true, 0},
+		{"amount due too high", Payment{BasePrice: 40000, Reservation: 250, AmountDue: 40000}, true, false, 250},
+		{"stated total differs", Payment{BasePrice: 40000, StatedTotal: 41000, AmountDue: 40000}, true, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := tt.payment.Reconcile()
+			if ok != tt.ok {
+				t.Fatalf("Reconcile() ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if r.Balanced() != tt.balanced || r.Difference != tt.difference {
+				t.Errorf("Reconcile() = %+v, balanced %v; want balanced %v, difference %v", r, r.Balanced(), tt.balanced, tt.difference)
+			}
+		})
+	}
+}
+
+func TestExtractPaymentCurrencyFromMarket(t *testing.T) {
+	tests := []struct {
+		name   string
+		market Market
+		order  DetailedOrder
+		want   string
+	}{
+		{"default market", Market{}, DetailedOrder{}, "EUR"},
+		{"order country", Market{}, DetailedOrder{Order: Order{CountryCode: "TR"}}, "TRY"},
+		{"configured market", Market{Country: "GB"}, DetailedOrder{Order: Order{CountryCode: "TR"}}, "GBP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &OrderManager{Market: tt.market}
+			if got := m.ExtractPayment(tt.order).Currency; got != tt.want {
+				t.Errorf("currency = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		market   Market
+		want     string
+	}{
+		{1234567.891, "EUR", Market{Country: "DE", Language: "de"}, "1.234.567,89 €"},
+		{1234.5, "EUR", Market{Country: "FR", Language: "fr"}, "1\u00a0234,50 €"},
+		{1234.5, "USD", Market{Country: "US", Language: "en"}, "$1,234.50"},
+		{-99.999, "GBP", Market{Country: "GB", Language: "en"}, "-£100.00"},
+		{2500000, "TRY", Market{Country: "TR", Language: "tr"}, "2.500.000,00 ₺"},
+		{12345, "CHF", Market{Country: "CH", Language: "de"}, "12’345.00 CHF"},
+		{5600000, "JPY", Market{Country: "JP", Language: "ja"}, "5,600,000 ￥"},
+		{100, "AED", Market{Country: "AE", Language: "en"}, "100.00 AED"},
+		{100, "", Market{Country: "DE", Language: "de"}, "100,00"},
+		{100, "XYZ", Market{Country: "DE", Language: "de"}, "100,00 XYZ"},
+		{1234.5, "USD", Market{Country: "TR", Language: "tr"}, "1.234,50 $"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatAmount(tt.amount, tt.currency, tt.market); got != tt.want {
+			t.Errorf("FormatAmount(%v, %q, %v) = %q, want %q", tt.amount, tt.currency, tt.market, got, tt.want)
+		}
+	}
+}
@@ -1,98 +1,314 @@
 package tesla
 
-type TeslaStore struct {
-	ID    int
-	Label string
-}
-
-func GetTeslaStoreByID(id int) TeslaStore {
-	if store, ok := teslaStores[id]; ok {
-		return store
-	}
-	return TeslaStore{0, "N/A"}
-}
-
-var teslaStores = map[int]TeslaStore{
-	0: {0, "N/A"},
-	
-	436108: {436108, "Dornbirn Mühlebach Pop Up"},
-	18438:  {18438, "Graz Kalsdorf"},
-	2938:   {2938, "Innsbruck"},
-	8730:   {8730, "Klagenfurt"},
-	14839:  {14839, "Linz"},
-	9340:   {9340, "Wien"},
-	18435:  {18435, "Salzburg"},
-
-	
-	32061:  {32061, "Awans"},
-	14852:  {14852, "Brugge"},
-
-	
-	14499:  {14499, "Praha"},
-
-	
-	301419: {301419, "Aalborg Storcenter"},
-	436102: {436102, "HerningCentret Pop Up"},
-
-	
-	438603: {438603, "Espo Pop Up"},
-	9118:   {9118, "Turku"},
-	26258:  {26258, "Vantaa Petikko"},
-
-	
-	446007: {446007, "Boutique éphémère Tesla Nice Cap3000"},
-	26558:  {26558, "Centre Tesla Aix-Marseille"},
-	30673:  {30673, "Rennes Pacé"},
-	26278:  {26278, "Rouen Store"},
-	413853: {413853, "Tesla Bayonne"},
-	407259: {407259, "Tesla Paris St-Ouen"},
-	4004152: {4004152, "Toulon Delivery Hub"},
-
-	
-	3693:   {3693, "Augsburg Gersthofen"},
-	9194:   {9194, "Berlin - Mall of Berlin"},
-	18426:  {18426, "Berlin Reinickendorf"},
-	9556:   {9556, "Berlin Schönefeld"},
-	16302:  {16302, "Berlin Schönefeld Delivery Hub"},
-	25762:  {25762, "Braunschweig Ölper"},
-	10512:  {10512, "Bremen Ottersberg"},
-	9467:   {9467, "Dortmund Holzwickede"},
-	399978: {399978, "Dortmund Innenstadt-Nord"},
-	14848:  {14848, "Dresden Kesselsdorf"},
-	13495:  {13495, "Duisburg Obermeiderich"},
-	14845:  {14845, "Düsseldorf Lierenfeld"},
-	439754: {439754, "Flensburg Gallerie Pop Up"},
-	20906:  {20906, "Frankfurt Ostend"},
-	9093:   {9093, "Freiburg Gundelfingen"},
-	28719:  {28719, "Fürth Hardhöhe"},
-	28725:  {28725, "Gießen An der Automeile"},
-	4225:   {4225, "Hamburg Wandsbek"},
-	3951:   {3951, "Hannover Wülfel"},
-	438015: {438015, "Heidelberg Altstadt Pop Up"},
-	3692:   {3692, "Heilbronn Sontheim"},
-	18430:  {18430, "Ingolstadt Oberhaunstadt"},
-	3690:   {3690, "Karlsruhe Rintheim"},
-	9095:   {9095, "Kiel Gettorf"},
-	18422:  {18422, "Koblenz Mülheim-Kärlich"},
-	2841:   {2841, "Köln Mülheim"},
-	20823:  {20823, "Magdeburg Großer Silberberg"},
-	1501:   {1501, "Mannheim Friedrichsfeld"},
-	2614:   {2614, "München Freiham"},
-	18423:  {18423, "München Parsdorf"},
-	15929:  {15929, "Neu-Ulm Schwaighofen"},
-	1250:   {1250, "Nürnberg St. Jobst"},
-	439780: {439780, "Rosenheim Innenstadt Pop Up"},
-	9098:   {9098, "Rostock Nienhagen"},
-	26292:  {26292, "Saarbrücken Brebach-Fechingen"},
-	27044:  {27044, "Stuttgart Holzgerlingen Sales, Used Car & Delivery Center"},
-	28717:  {28717, "Stuttgart Weinstadt"},
-
-	
-	425125: {425125, "Tesla Armada AVM"},
-	445210: {445210, "Tesla Ferko Line"},
-	449153: {449153, "Tesla Istanbul Meydan AVM"},
-	451952: {451952, "Tesla İstinyePark İzmir"},
-	410805: {410805, "Tesla Ankara Delivery Hub"},
-	442359: {442359, "Tesla Delivery Istanbul"},
-	460569: {460569, "Tesla Delivery Gaziemir Izmir"},
-} 
\ No newline at end of file
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// bundledStores is the embedded delivery center list. It was compiled from
+// the store names the app shipped with, which carry no address, so every
+// entry has the coordinates of its town and is marked Approximate. Street
+// addresses and site coordinates come from StoresFile until the list has
+// verified ones.
+//
+//go:embed data/stores.json
+var bundledStores []byte
+
+// Store is a Tesla delivery center, identified by the vehicleRoutingLocation
+// of the orders delivered there.
+type Store struct {
+	ID        int     `json:"id"`
+	Name      string  `json:"name"`
+	Country   string  `json:"country,omitempty"`
+	City      string  `json:"city,omitempty"`
+	Address   string  `json:"address,omitempty"`
+	Latitude  float64 `json:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty"`
+	// Approximate is set when the coordinates are those of the town rather
+	// than of the site itself.
+	Approximate bool `json:"approximate,omitempty"`
+}
+
+// HasLocation reports whether the store has coordinates.
+func (s Store) HasLocation() bool {
+	return s.Latitude != 0 || s.Longitude != 0
+}
+
+// Location returns the coordinates of the store, labelled with its name.
+func (s Store) Location() Location {
+	return Location{Label: s.Name, Latitude: s.Latitude, Longitude: s.Longitude}
+}
+
+// StoreDirectory is a list of delivery centers. Version is free-form, by
+// convention the date the list was compiled.
+type StoreDirectory struct {
+	Version string  `json:"version,omitempty"`
+	Stores  []Store `json:"stores"`
+}
+
+// StoresFile returns the location of the user's store additions and
+// corrections, which take precedence over the bundled list.
+func StoresFile() string {
+	return filepath.Join(ConfigDir, "stores.json")
+}
+
+var (
+	storesMu sync.Mutex
+	stores   *StoreDirectory
+)
+
+// Stores returns the bundled directory with StoresFile merged over it. It is
+// loaded on first use; call ReloadStores after changing StoresFile.
+func Stores() *StoreDirectory {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+
+	if stores == nil {
+		var err error
+		if stores, err = LoadStores(StoresFile()); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+	return stores
+}
+
+// ReloadStores reads StoresFile again.
+func ReloadStores() error {
+	directory, err := LoadStores(StoresFile())
+
+	storesMu.Lock()
+	stores = directory
+	storesMu.Unlock()
+
+	return err
+}
+
+// LoadStores returns the bundled directory with the stores of overrides
+// merged over it. A missing overrides file is not an error; an invalid one
+// leaves the bundled directory.
+func LoadStores(overrides string) (*StoreDirectory, error) {
+	directory, err := ParseStores(bundledStores)
+	if err != nil {
+		panic(fmt.Sprintf("invalid bundled store list: %v", err))
+	}
+
+	user, err := ReadStoresFile(overrides)
+	if err != nil {
+		return &directory, err
+	}
+	directory.Merge(user)
+	return &directory, nil
+}
+
+// ReadStoresFile reads a store list in JSON or CSV. A missing file is an
+// empty list.
+func ReadStoresFile(path string) (StoreDirectory, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return StoreDirectory{}, nil
+	}
+	if err != nil {
+		return StoreDirectory{}, err
+	}
+
+	directory, err := ParseStores(data)
+	if err != nil {
+		return StoreDirectory{}, fmt.Errorf("invalid store list %s: %w", path, err)
+	}
+	return directory, nil
+}
+
+// RoutingLocation returns the ID of the delivery center the order is routed
+// to, or 0 if it has none yet.
+func RoutingLocation(order DetailedOrder) int {
+	registration := order.Details.TypedTasks().Registration
+	if registration == nil || registration.OrderDetails == nil {
+		return 0
+	}
+	location := registration.OrderDetails.VehicleRoutingLocation
+	if !location.Valid {
+		return 0
+	}
+	return int(location.Value)
+}
+
+// Save writes the directory as JSON.
+func (d *StoreDirectory) Save(path string) error {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// Lookup returns the store with the given routing location ID.
+func (d *StoreDirectory) Lookup(id int) (Store, bool) {
+	if d == nil {
+		return Store{}, false
+	}
+	for _, store := range d.Stores {
+		if store.ID == id {
+			return store, true
+		}
+	}
+	return Store{}, false
+}
+
+// Merge applies an update to the directory: unknown stores are added and
+// known ones take the fields the update sets. It returns the number of
+// stores added and changed.
+func (d *StoreDirectory) Merge(update StoreDirectory) (added, changed int) {
+	for _, store := range update.Stores {
+		i := slices.IndexFunc(d.Stores, func(s Store) bool { return s.ID == store.ID })
+		if i < 0 {
+			d.Stores = append(d.Stores, store)
+			added++
+			continue
+		}
+
+		merged := mergeStore(d.Stores[i], store)
+		if merged != d.Stores[i] {
+			d.Stores[i] = merged
+			changed++
+		}
+	}
+
+	if update.Version > d.Version {
+		d.Version = update.Version
+	}
+	return added, changed
+}
+
+func mergeStore(store, update Store) Store {
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&store.Name, update.Name},
+		{&store.Country, update.Country},
+		{&store.City, update.City},
+		{&store.Address, update.Address},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+
+	if update.HasLocation() {
+		store.Latitude = update.Latitude
+		store.Longitude = update.Longitude
+		store.Approximate = update.Approximate
+	}
+	return store
+}
+
+// csvStoreColumns are the columns of a CSV store list, which must start
+// with a header naming them; id is required, the others are optional.
+var csvStoreColumns = []string{"id", "name", "country", "city", "address", "latitude", "longitude", "approximate"}
+
+// ParseStores parses a store list given as JSON or as CSV with a header
+// row.
+func ParseStores(data []byte) (StoreDirectory, error) {
+	var directory StoreDirectory
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return directory, nil
+	}
+
+	if trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &directory); err != nil {
+			return directory, err
+		}
+	} else {
+		stores, err := parseStoresCSV(trimmed)
+		if err != nil {
+			return directory, err
+		}
+		directory.Stores = stores
+	}
+
+	for _, store := range directory.Stores {
+		if store.ID <= 0 {
+			return directory, fmt.Errorf("store %q has no valid id", store.Name)
+		}
+	}
+	return directory, nil
+}
+
+func parseStoresCSV(data []byte) ([]Store, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(csvStoreColumns, name) {
+			return nil, fmt.Errorf("unknown column %q, expected %s", name, strings.Join(csvStoreColumns, ", "))
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["id"]; !ok {
+		return nil, fmt.Errorf("missing id column")
+	}
+
+	var stores []Store
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return stores, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		value := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		line, _ := reader.FieldPos(0)
+		store := Store{
+			Name:    value("name"),
+			Country: strings.ToUpper(value("country")),
+			City:    value("city"),
+			Address: value("address"),
+		}
+		if store.ID, err = strconv.Atoi(value("id")); err != nil {
+			return nil, fmt.Errorf("line %d: invalid id %q", line, value("id"))
+		}
+		for _, coordinate := range []struct {
+			column string
+			dst    *float64
+		}{{"latitude", &store.Latitude}, {"longitude", &store.Longitude}} {
+			if v := value(coordinate.column); v != "" {
+				if *coordinate.dst, err = strconv.ParseFloat(v, 64); err != nil {
+					return nil, fmt.Errorf("line %d: invalid %s %q", line, coordinate.column, v)
+				}
+			}
+		}
+		if v := value("approximate"); v != "" {
+			if store.Approximate, err = strconv.ParseBool(v); err != nil {
+				return nil, fmt.Errorf("line %d: invalid approximate %q", line, v)
+			}
+		}
+
+		stores = append(stores, store)
+	}
+}
@@ -0,0 +1,132 @@
+package tesla
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBundledStores(t *testing.T) {
+	directory, err := LoadStores(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, ok := directory.Lookup(2614)
+	if !ok || store.Name != "München Freiham" || store.Country != "DE" || !store.HasLocation() {
+		t.Errorf("Lookup(2614) = %+v, %v", store, ok)
+	}
+	if _, ok := directory.Lookup(1); ok {
+		t.Error("Lookup(1) found a store")
+	}
+
+	seen := make(map[int]bool)
+	for _, store := range directory.Stores {
+		if seen[store.ID] {
+			t.Errorf("store %d listed twice", store.ID)
+		}
+		seen[store.ID] = true
+		if store.Name == "" || store.Country == "" {
+			t.Errorf("store %d has no name or country", store.ID)
+		}
+		// Only sites with a verified address may claim exact coordinates.
+		if store.Address == "" && !store.Approximate {
+			t.Errorf("store %d has no address but is not marked approximate", store.ID)
+		}
+	}
+}
+
+func TestStoreDirectoryMerge(t *testing.T) {
+	directory := StoreDirectory{
+		Version: "2025-01-01",
+		Stores: []Store{
+			{ID: 1, Name: "Old", Country: "DE", City: "Berlin", Latitude: 52.5, Longitude: 13.4, Approximate: true},
+			{ID: 2, Name: "Kept", Country: "DE"},
+		},
+	}
+
+	added, changed := directory.Merge(StoreDirectory{
+		Version: "2025-02-01",
+		Stores: []Store{
+			{ID: 1, Address: "Street 1", Latitude: 52.51, Longitude: 13.41},
+			{ID: 2, Name: "Kept"},
+			{ID: 3, Name: "New", Country: "TR"},
+		},
+	})
+	if added != 1 || changed != 1 {
+		t.Errorf("Merge() = %d added, %d changed, want 1, 1", added, changed)
+	}
+	if directory.Version != "2025-02-01" {
+		t.Errorf("Version = %q", directory.Version)
+	}
+
+	want := Store{ID: 1, Name: "Old", Country: "DE", City: "Berlin", Address: "Street 1", Latitude: 52.51, Longitude: 13.41}
+	if got, _ := directory.Lookup(1); got != want {
+		t.Errorf("merged store = %+v, want %+v", got, want)
+	}
+	if got, ok := directory.Lookup(3); !ok || got.Name != "New" {
+		t.Errorf("added store = %+v, %v", got, ok)
+	}
+}
+
+func TestParseStoresCSV(t *testing.T) {
+	directory, err := ParseStores([]byte("id,name,country,latitude,longitude\n" +
+		"2614, München Freiham, de, 48.1452, 11.4107\n" +
+		"99,Only a name,,,\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Store{
+		{ID: 2614, Name: "München Freiham", Country: "DE", Latitude: 48.1452, Longitude: 11.4107},
+		{ID: 99, Name: "Only a name"},
+	}
+	if len(directory.Stores) != len(want) {
+		t.Fatalf("Stores = %+v, want %+v", directory.Stores, want)
+	}
+	for i := range want {
+		if directory.Stores[i] != want[i] {
+			t.Errorf("Stores[%d] = %+v, want %+v", i, directory.Stores[i], want[i])
+		}
+	}
+
+	for _, invalid := range []string{
+		"name\nNo id\n",
+		"id,name,colour\n1,x,red\n",
+		"id,latitude\n1,north\n",
+		`{"stores":[{"name":"No id"}]}`,
+	} {
+		if _, err := ParseStores([]byte(invalid)); err == nil {
+			t.Errorf("ParseStores(%q) accepted an invalid list", invalid)
+		}
+	}
+}
+
+func TestLoadStoresOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stores.json")
+	if err := os.WriteFile(path, []byte(`{"stores":[{"id":2614,"name":"Freiham"},{"id":123,"name":"Custom","country":"NL"}]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	directory, err := LoadStores(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store, _ := directory.Lookup(2614); store.Name != "Freiham" || store.Country != "DE" {
+		t.Errorf("overridden store = %+v", store)
+	}
+	if _, ok := directory.Lookup(123); !ok {
+		t.Error("store added by the overrides file not found")
+	}
+
+	if err := os.WriteFile(path, []byte(`{`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	directory, err = LoadStores(path)
+	if err == nil {
+		t.Error("LoadStores() accepted an invalid overrides file")
+	}
+	if _, ok := directory.Lookup(2614); !ok {
+		t.Error("invalid overrides file dropped the bundled stores")
+	}
+}
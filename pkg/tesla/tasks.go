@@ -0,0 +1,224 @@
+package tesla
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Field is a scalar task field that remembers whether Tesla sent the key,
+// so a missing field can be told apart from a null or empty one. Tesla sends
+// some numbers as strings and some strings as numbers, so a string field
+// accepts a number and a numeric field a string holding one. Any other value
+// of an unexpected JSON type leaves Valid unset instead of failing the
+// decode.
+type Field[T any] struct {
+	Value   T
+	Present bool
+	Valid   bool
+	// Text is the string Tesla sent for a numeric field, whether or not it
+	// parses as a number.
+	Text string
+}
+
+func (f *Field[T]) UnmarshalJSON(data []byte) error {
+	f.Present = true
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if json.Unmarshal(data, &f.Value) == nil {
+		f.Valid = true
+		return nil
+	}
+
+	switch value := any(&f.Value).(type) {
+	case *string:
+		var number json.Number
+		if json.Unmarshal(data, &number) == nil {
+			*value, f.Valid = number.String(), true
+		}
+	case *float64:
+		var text string
+		if json.Unmarshal(data, &text) == nil {
+			f.Text = strings.TrimSpace(text)
+			if number, err := strconv.ParseFloat(f.Text, 64); err == nil {
+				*value, f.Valid = number, true
+			}
+		}
+	}
+	return nil
+}
+
+// TaskState is the progress every task reports.
+type TaskState struct {
+	Complete bool      `json:"complete"`
+	Enabled  bool      `json:"enabled"`
+	Status   string    `json:"status"`
+	Card     *TaskCard `json:"card"`
+}
+
+// TaskCard is the text Tesla shows for a task in its app, telling what to
+// do next or what is being waited for.
+type TaskCard struct {
+	Title        Field[string] `json:"title"`
+	Subtitle     Field[string] `json:"subtitle"`
+	MessageTitle Field[string] `json:"messageTitle"`
+	MessageBody  Field[string] `json:"messageBody"`
+}
+
+// UnmarshalJSON decodes the card tolerantly, so a card of another shape
+// only loses its text and not the task: a plain string becomes the title
+// and anything else is ignored.
+func (c *TaskCard) UnmarshalJSON(data []byte) error {
+	type card TaskCard
+	var decoded card
+	if json.Unmarshal(data, &decoded) == nil {
+		*c = TaskCard(decoded)
+		return nil
+	}
+
+	var text string
+	if json.Unmarshal(data, &text) == nil {
+		c.Title = Field[string]{Value: text, Present: true, Valid: true}
+	}
+	return nil
+}
+
+// Text returns the non-empty parts of the card, one per line.
+func (c *TaskCard) Text() string {
+	if c == nil {
+		return ""
+	}
+	var lines []string
+	for _, field := range []Field[string]{c.Title, c.Subtitle, c.MessageTitle, c.MessageBody} {
+		if text := strings.TrimSpace(field.Value); field.Valid && text != "" && !slices.Contains(lines, text) {
+			lines = append(lines, text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Tasks is the typed view of the tasks the app knows. A task missing from
+// the payload, or not an object, is nil.
+type Tasks struct {
+	Registration    *RegistrationTask
+	Scheduling      *SchedulingTask
+	FinalPayment    *FinalPaymentTask
+	TradeIn         *TradeInTask
+	Financing       *FinancingTask
+	DeliveryDetails *DeliveryDetailsTask
+}
+
+type RegistrationTask struct {
+	TaskState
+	OrderDetails *RegistrationDetails `json:"orderDetails"`
+}
+
+type RegistrationDetails struct {
+	ReservationDate           Field[string]  `json:"reservationDate"`
+	OrderBookedDate           Field[string]  `json:"orderBookedDate"`
+	VehicleOdometer           Field[float64] `json:"vehicleOdometer"`
+	VehicleOdometerType       Field[string]  `json:"vehicleOdometerType"`
+	VehicleRoutingLocation    Field[float64] `json:"vehicleRoutingLocation"`
+	ReservationAmountReceived Field[float64] `json:"reservationAmountReceived"`
+	CountryCode               Field[string]  `json:"countryCode"`
+	CurrencyCode              Field[string]  `json:"currencyCode"`
+}
+
+type SchedulingTask struct {
+	TaskState
+	DeliveryWindowDisplay  Field[string] `json:"deliveryWindowDisplay"`
+	ApptDateTimeAddressStr Field[string] `json:"apptDateTimeAddressStr"`
+}
+
+type FinalPaymentTask struct {
+	TaskState
+	AmountDue      Field[float64] `json:"amountDue"`
+	CurrencyCode   Field[string]  `json:"currencyCode"`
+	CurrencyFormat struct {
+		CurrencyCode Field[string] `json:"currencyCode"`
+	} `json:"currencyFormat"`
+	Data *FinalPaymentData `json:"data"`
+}
+
+type FinalPaymentData struct {
+	ETAToDeliveryCenter Field[string]   `json:"etaToDeliveryCenter"`
+	AmountDue           Field[float64]  `json:"amountDue"`
+	CurrencyCode        Field[string]   `json:"currencyCode"`
+	TradeInAmount       Field[float64]  `json:"tradeInAmount"`
+	TradeInCredit       Field[float64]  `json:"tradeInCredit"`
+	LoanAmount          Field[float64]  `json:"loanAmount"`
+	FinancedAmount      Field[float64]  `json:"financedAmount"`
+	PaymentDetails      []PaymentDetail `json:"paymentDetails"`
+	BasePrice           Field[float64]  `json:"basePrice"`
+	OptionsPrice        Field[float64]  `json:"optionsPrice"`
+	DestinationFee      Field[float64]  `json:"destinationFee"`
+	OrderFee            Field[float64]  `json:"orderFee"`
+	TotalPrice          Field[float64]  `json:"totalPrice"`
+	Incentives          []PricingItem   `json:"incentives"`
+}
+
+// PricingItem is a named amount of the price breakdown, such as an
+// incentive.
+type PricingItem struct {
+	Description Field[string]  `json:"description"`
+	Amount      Field[float64] `json:"amount"`
+}
+
+type PaymentDetail struct {
+	PaymentType Field[string]  `json:"paymentType"`
+	AmountPaid  Field[float64] `json:"amountPaid"`
+	Amount      Field[float64] `json:"amount"`
+}
+
+type TradeInTask struct {
+	TaskState
+	TradeInAmount Field[float64] `json:"tradeInAmount"`
+}
+
+type FinancingTask struct {
+	TaskState
+	LoanAmount Field[float64] `json:"loanAmount"`
+	Incentives []PricingItem  `json:"incentives"`
+}
+
+type DeliveryDetailsTask struct {
+	TaskState
+}
+
+// TypedTasks decodes the raw tasks into the typed model. Each task is
+// decoded on its own, so one task changing shape does not hide the others.
+func (d OrderDetails) TypedTasks() Tasks {
+	var tasks Tasks
+	decodeTask(d.Tasks, "registration", &tasks.Registration)
+	decodeTask(d.Tasks, "scheduling", &tasks.Scheduling)
+	decodeTask(d.Tasks, "finalPayment", &tasks.FinalPayment)
+	decodeTask(d.Tasks, "tradeIn", &tasks.TradeIn)
+	decodeTask(d.Tasks, "financing", &tasks.Financing)
+	decodeTask(d.Tasks, "deliveryDetails", &tasks.DeliveryDetails)
+	return tasks
+}
+
+func decodeTask[T any](raw map[string]interface{}, key string, task **T) {
+	value, ok := raw[key].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+
+	// A member of an unexpected type is skipped by Unmarshal, which still
+	// decodes the rest of the task, so only that member is lost.
+	decoded := new(T)
+	var typeErr *json.UnmarshalTypeError
+	if err := json.Unmarshal(data, decoded); err != nil && !errors.As(err, &typeErr) {
+		return
+	}
+	*task = decoded
+}
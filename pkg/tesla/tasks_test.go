@@ -0,0 +1,74 @@
+package tesla
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTypedTasks(t *testing.T) {
+	var details OrderDetails
+	err := json.Unmarshal([]byte(`{"tasks":{
+		"registration":{"complete":true,"orderDetails":{"reservationDate":"2025-01-10","vehicleOdometer":"unknown"}},
+		"scheduling":"not an object",
+		"finalPayment":{"complete":false,"status":"MAKE_YOUR_FINAL_PAYMENT","amountDue":100,"data":{"paymentDetails":[{"amountPaid":5}]}},
+		"financing":{"enabled":"yes","loanAmount":"25000.50"},
+		"someFutureTask":{"complete":true}
+	}}`), &details)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tasks := details.TypedTasks()
+
+	registration := tasks.Registration
+	if registration == nil || !registration.Complete || registration.OrderDetails == nil {
+		t.Fatalf("Registration = %+v", registration)
+	}
+	if date := registration.OrderDetails.ReservationDate; !date.Valid || date.Value != "2025-01-10" {
+		t.Errorf("ReservationDate = %+v", date)
+	}
+	if odometer := registration.OrderDetails.VehicleOdometer; !odometer.Present || odometer.Valid {
+		t.Errorf("VehicleOdometer of the wrong type = %+v, want present but invalid", odometer)
+	}
+	if orderBooked := registration.OrderDetails.OrderBookedDate; orderBooked.Present {
+		t.Errorf("missing OrderBookedDate = %+v, want not present", orderBooked)
+	}
+
+	if tasks.Scheduling != nil {
+		t.Errorf("Scheduling = %+v, want nil for a non-object task", tasks.Scheduling)
+	}
+	// A member of the wrong type is lost, not the whole task.
+	financing := tasks.Financing
+	if financing == nil || financing.Enabled {
+		t.Fatalf("Financing = %+v, want the task without Enabled", financing)
+	}
+	if loan := financing.LoanAmount; !loan.Valid || loan.Value != 25000.50 {
+		t.Errorf("LoanAmount sent as a string = %+v", loan)
+	}
+
+	finalPayment := tasks.FinalPayment
+	if finalPayment == nil || finalPayment.Status != "MAKE_YOUR_FINAL_PAYMENT" || finalPayment.AmountDue.Value != 100 {
+		t.Fatalf("FinalPayment = %+v", finalPayment)
+	}
+	if finalPayment.Data == nil || len(finalPayment.Data.PaymentDetails) != 1 {
+		t.Errorf("FinalPayment.Data = %+v", finalPayment.Data)
+	}
+
+	if _, ok := details.Tasks["someFutureTask"]; !ok {
+		t.Error("raw tasks lost an unknown task")
+	}
+}
+
+func TestTypedTasksKeepsTaskWithBadStatus(t *testing.T) {
+	details := OrderDetails{Tasks: map[string]interface{}{
+		"finalPayment": map[string]interface{}{"status": 0.0, "amountDue": 1500.0},
+	}}
+
+	finalPayment := details.TypedTasks().FinalPayment
+	if finalPayment == nil {
+		t.Fatal("FinalPayment = nil, want the task without its status")
+	}
+	if finalPayment.Status != "" || !finalPayment.AmountDue.Valid || finalPayment.AmountDue.Value != 1500 {
+		t.Errorf("FinalPayment = %+v", finalPayment)
+	}
+}
@@ -0,0 +1,284 @@
+package tesla
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Environment variables selecting where tokens are stored.
+const (
+	// TokenStoreEnv forces a store: "keyring", "encrypted" or "file". By
+	// default the OS keyring is preferred, then the encrypted file if a
+	// passphrase is set, then the plaintext file.
+	TokenStoreEnv = "TESLA_TOKEN_STORE"
+	// TokenPassphraseEnv holds the passphrase of the encrypted token file.
+	TokenPassphraseEnv = "TESLA_TOKEN_PASSPHRASE"
+)
+
+// ErrNoTokens is returned by TokenStore.Load when no tokens are saved.
+var ErrNoTokens = errors.New("no saved tokens")
+
+// Tokens are the credentials saved for an account.
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenStore saves the tokens of one account.
+type TokenStore interface {
+	Load() (Tokens, error)
+	Save(tokens Tokens) error
+	Delete() error
+}
+
+// NewTokenStore returns the store for the tokens whose plaintext location is
+// tokenFile. Tokens found in the plaintext file are moved to the keyring or
+// encrypted file on first load.
+func NewTokenStore(tokenFile string) TokenStore {
+	plain := &PlainFileStore{Path: tokenFile}
+	keyring := &KeyringStore{Service: KeyringService, User: tokenFile}
+	passphrase := os.Getenv(TokenPassphraseEnv)
+
+	var encrypted TokenStore
+	if passphrase != "" {
+		encrypted = &EncryptedFileStore{Path: strings.TrimSuffix(tokenFile, ".json") + ".enc", Passphrase: passphrase}
+	}
+
+	switch os.Getenv(TokenStoreEnv) {
+	case "file":
+		return plain
+	case "keyring":
+		return &migratingStore{stores: []TokenStore{keyring}, legacy: plain}
+	case "encrypted":
+		if passphrase == "" {
+			return &failingStore{err: fmt.Errorf("%s=encrypted requires %s", TokenStoreEnv, TokenPassphraseEnv)}
+		}
+		return &migratingStore{stores: []TokenStore{encrypted}, legacy: plain}
+	}
+
+	stores := []TokenStore{keyring}
+	if passphrase != "" {
+		stores = append(stores, encrypted)
+	}
+	return &migratingStore{stores: stores, legacy: plain, allowPlain: true}
+}
+
+// migratingStore uses the first of stores that works and moves tokens found
+// in the legacy plaintext file into it. With allowPlain, the plaintext file
+// is still used when none of the stores is available.
+type migratingStore struct {
+	stores     []TokenStore
+	legacy     *PlainFileStore
+	allowPlain bool
+}
+
+func (s *migratingStore) Load() (Tokens, error) {
+	var errs []error
+	for _, store := range s.stores {
+		tokens, err := store.Load()
+		if err == nil {
+			return tokens, nil
+		}
+		if !errors.Is(err, ErrNoTokens) && !errors.Is(err, errKeyringUnavailable) {
+			errs = append(errs, err)
+		}
+	}
+
+	tokens, err := s.legacy.Load()
+	if errors.Is(err, ErrNoTokens) && len(errs) > 0 {
+		return Tokens{}, errors.Join(errs...)
+	}
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	if err := s.Save(tokens); err != nil {
+		fmt.Printf("Warning: Could not move tokens out of %s: %v\n", s.legacy.Path, err)
+	}
+	return tokens, nil
+}
+
+// Save writes the tokens to the first store that accepts them and removes
+// the plaintext file once they are stored securely.
+func (s *migratingStore) Save(tokens Tokens) error {
+	var errs []error
+	for _, store := range s.stores {
+		err := store.Save(tokens)
+		if err == nil {
+			return s.legacy.Delete()
+		}
+		errs = append(errs, err)
+	}
+
+	if s.allowPlain {
+		return s.legacy.Save(tokens)
+	}
+	return errors.Join(errs...)
+}
+
+func (s *migratingStore) Delete() error {
+	var errs []error
+	for _, store := range append(s.stores, s.legacy) {
+		if err := store.Delete(); err != nil && !errors.Is(err, errKeyringUnavailable) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// failingStore reports a configuration error on every use.
+type failingStore struct {
+	err error
+}
+
+func (s *failingStore) Load() (Tokens, error) { return Tokens{}, s.err }
+func (s *failingStore) Save(Tokens) error     { return s.err }
+func (s *failingStore) Delete() error         { return s.err }
+
+// PlainFileStore keeps the tokens as plaintext JSON, the format used before
+// the keyring and encrypted stores existed.
+type PlainFileStore struct {
+	Path string
+}
+
+func (s *PlainFileStore) Load() (Tokens, error) {
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return Tokens{}, ErrNoTokens
+	}
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	var tokens Tokens
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return Tokens{}, fmt.Errorf("invalid token file %s: %w", s.Path, err)
+	}
+	return tokens, nil
+}
+
+func (s *PlainFileStore) Save(tokens Tokens) error {
+	data, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.Path, data, 0600)
+}
+
+func (s *PlainFileStore) Delete() error {
+	if err := os.Remove(s.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// pbkdf2Iterations is the PBKDF2-SHA256 work factor for new encrypted files.
+const pbkdf2Iterations = 600000
+
+// EncryptedFileStore keeps the tokens in a file encrypted with AES-256-GCM
+// under a key derived from Passphrase.
+type EncryptedFileStore struct {
+	Path       string
+	Passphrase string
+}
+
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// ErrWrongPassphrase is returned when the encrypted token file cannot be
+// decrypted with the given passphrase.
+var ErrWrongPassphrase = errors.New("wrong passphrase for the encrypted token file")
+
+func (s *EncryptedFileStore) Load() (Tokens, error) {
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return Tokens{}, ErrNoTokens
+	}
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil || file.Version != 1 {
+		return Tokens{}, fmt.Errorf("invalid encrypted token file %s", s.Path)
+	}
+
+	gcm, err := s.cipher(file.Salt, file.Iterations)
+	if err != nil {
+		return Tokens{}, err
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return Tokens{}, ErrWrongPassphrase
+	}
+
+	var tokens Tokens
+	if err := json.Unmarshal(plaintext, &tokens); err != nil {
+		return Tokens{}, fmt.Errorf("invalid encrypted token file %s: %w", s.Path, err)
+	}
+	return tokens, nil
+}
+
+func (s *EncryptedFileStore) Save(tokens Tokens) error {
+	plaintext, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+
+	file := encryptedFile{Version: 1, Iterations: pbkdf2Iterations, Salt: make([]byte, 16)}
+	if _, err := rand.Read(file.Salt); err != nil {
+		return err
+	}
+
+	gcm, err := s.cipher(file.Salt, file.Iterations)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Ciphertext = gcm.Seal(nil, file.Nonce, plaintext, nil)
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.Path, data, 0600)
+}
+
+func (s *EncryptedFileStore) Delete() error {
+	if err := os.Remove(s.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *EncryptedFileStore) cipher(salt []byte, iterations int) (cipher.AEAD, error) {
+	if s.Passphrase == "" {
+		return nil, fmt.Errorf("no passphrase set in %s", TokenPassphraseEnv)
+	}
+
+	key, err := pbkdf2.Key(sha256.New, s.Passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
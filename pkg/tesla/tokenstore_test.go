@@ -0,0 +1,157 @@
+package tesla
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// memoryStore stands in for the OS keyring.
+type memoryStore struct {
+	tokens *Tokens
+	err    error
+}
+
+func (s *memoryStore) Load() (Tokens, error) {
+	if s.err != nil {
+		return Tokens{}, s.err
+	}
+	if s.tokens == nil {
+		return Tokens{}, ErrNoTokens
+	}
+	return *s.tokens, nil
+}
+
+func (s *memoryStore) Save(tokens Tokens) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.tokens = &tokens
+	return nil
+}
+
+func (s *memoryStore) Delete() error {
+	if s.err != nil {
+		return s.err
+	}
+	s.tokens = nil
+	return nil
+}
+
+var storeTokens = Tokens{AccessToken: "access", RefreshToken: "refresh"}
+
+func TestEncryptedFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.enc")
+	store := &EncryptedFileStore{Path: path, Passphrase: "secret"}
+
+	if _, err := store.Load(); !errors.Is(err, ErrNoTokens) {
+		t.Fatalf("Load() before Save error = %v, want ErrNoTokens", err)
+	}
+	if err := store.Save(storeTokens); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "refresh") {
+		t.Errorf("encrypted file contains the plaintext token: %s", data)
+	}
+
+	got, err := store.Load()
+	if err != nil || got != storeTokens {
+		t.Errorf("Load() = %+v, %v, want %+v", got, err, storeTokens)
+	}
+
+	wrong := &EncryptedFileStore{Path: path, Passphrase: "guess"}
+	if _, err := wrong.Load(); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Load() with wrong passphrase error = %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestMigratingStoreMovesPlaintextTokens(t *testing.T) {
+	legacy := &PlainFileStore{Path: filepath.Join(t.TempDir(), "tokens.json")}
+	if err := legacy.Save(storeTokens); err != nil {
+		t.Fatal(err)
+	}
+
+	keyring := &memoryStore{}
+	store := &migratingStore{stores: []TokenStore{keyring}, legacy: legacy}
+
+	got, err := store.Load()
+	if err != nil || got != storeTokens {
+		t.Fatalf("Load() = %+v, %v, want %+v", got, err, storeTokens)
+	}
+	if keyring.tokens == nil || *keyring.tokens != storeTokens {
+		t.Errorf("keyring = %+v, want the migrated tokens", keyring.tokens)
+	}
+	if _, err := os.Stat(legacy.Path); !os.IsNotExist(err) {
+		t.Errorf("plaintext file still exists after migration: %v", err)
+	}
+}
+
+func TestMigratingStoreFallsBackToPlaintext(t *testing.T) {
+	legacy := &PlainFileStore{Path: filepath.Join(t.TempDir(), "tokens.json")}
+	unavailable := &memoryStore{err: errKeyringUnavailable}
+
+	strict := &migratingStore{stores: []TokenStore{unavailable}, legacy: legacy}
+	if err := strict.Save(storeTokens); !errors.Is(err, errKeyringUnavailable) {
+		t.Errorf("Save() without allowPlain error = %v, want errKeyringUnavailable", err)
+	}
+
+	store := &migratingStore{stores: []TokenStore{unavailable}, legacy: legacy, allowPlain: true}
+	if err := store.Save(storeTokens); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Load()
+	if err != nil || got != storeTokens {
+		t.Errorf("Load() = %+v, %v, want %+v", got, err, storeTokens)
+	}
+
+	if err := store.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(); !errors.Is(err, ErrNoTokens) {
+		t.Errorf("Load() after Delete error = %v, want ErrNoTokens", err)
+	}
+}
+
+func TestMigratingStoreReportsWrongPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	encrypted := &EncryptedFileStore{Path: filepath.Join(dir, "tokens.enc"), Passphrase: "secret"}
+	if err := encrypted.Save(storeTokens); err != nil {
+		t.Fatal(err)
+	}
+
+	wrong := &EncryptedFileStore{Path: encrypted.Path, Passphrase: "guess"}
+	store := &migratingStore{stores: []TokenStore{wrong}, legacy: &PlainFileStore{Path: filepath.Join(dir, "tokens.json")}}
+	if _, err := store.Load(); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Load() error = %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestNewTokenStoreFromEnvironment(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "tokens.json")
+
+	t.Setenv(TokenStoreEnv, "file")
+	if _, ok := NewTokenStore(tokenFile).(*PlainFileStore); !ok {
+		t.Errorf("%s=file did not select the plaintext store", TokenStoreEnv)
+	}
+
+	t.Setenv(TokenStoreEnv, "encrypted")
+	t.Setenv(TokenPassphraseEnv, "")
+	if err := NewTokenStore(tokenFile).Save(storeTokens); err == nil {
+		t.Error("encrypted store without a passphrase saved tokens")
+	}
+
+	t.Setenv(TokenPassphraseEnv, "secret")
+	if err := NewTokenStore(tokenFile).Save(storeTokens); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(tokenFile), "tokens.enc")); err != nil {
+		t.Errorf("encrypted token file not written: %v", err)
+	}
+}
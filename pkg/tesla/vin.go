@@ -0,0 +1,208 @@
+package tesla
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// VINInfo is what the 17 characters of a Tesla VIN encode. Descriptions
+// are empty for codes the decoder does not know; the code fields always
+// hold the raw character.
+type VINInfo struct {
+	VIN          string
+	Manufacturer string
+	Model        string
+	Body         string
+	BatteryCode  string
+	Battery      string
+	MotorCode    string
+	Motor        string
+	ModelYear    int
+	PlantCode    string
+	Plant        string
+	Serial       string
+	// CheckDigitValid reports whether the 9th character matches the check
+	// digit computed from the others.
+	CheckDigitValid bool
+}
+
+var vinManufacturers = map[string]string{
+	"5YJ": "Tesla, Inc. (USA)",
+	"7SA": "Tesla, Inc. (USA)",
+	"7G2": "Tesla, Inc. (USA)",
+	"LRW": "Tesla (China)",
+	"XP7": "Tesla Manufacturing Brandenburg (Germany)",
+	"SFZ": "Tesla (UK)",
+}
+
+var vinModels = map[byte]string{
+	'S': "Model S",
+	'3': "Model 3",
+	'X': "Model X",
+	'Y': "Model Y",
+	'C': "Cybertruck",
+	'R': "Roadster",
+	'T': "Semi",
+}
+
+var vinBodies = map[byte]string{
+	'A': "Hatchback 5-door, left-hand drive",
+	'B': "Hatchback 5-door, right-hand drive",
+	'C': "SUV 5-door, left-hand drive",
+	'D': "SUV 5-door, right-hand drive",
+	'E': "Sedan 4-door, left-hand drive",
+	'F': "Sedan 4-door, right-hand drive",
+	'G': "SUV 5-door, left-hand drive",
+	'H': "SUV 5-door, right-hand drive",
+}
+
+var vinBatteries = map[byte]string{
+	'E': "Electric, NMC battery",
+	'F': "Electric, LFP battery",
+	'H': "High capacity battery",
+	'S': "Standard capacity battery",
+}
+
+var vinMotors = map[byte]string{
+	'1': "Single motor",
+	'2': "Dual motor",
+	'3': "Single motor, performance",
+	'4': "Dual motor, performance",
+	'A': "Single motor",
+	'B': "Dual motor",
+	'C': "Dual motor, performance",
+}
+
+var vinPlants = map[byte]string{
+	'F': "Fremont",
+	'A': "Austin",
+	'B': "Berlin",
+	'C': "Shanghai",
+}
+
+// vinYears are the model year codes from 2010 on; the cycle repeats every
+// 30 years.
+const vinYears = "ABCDEFGHJKLMNPRSTVWXY123456789"
+
+// DecodeVIN decodes a Tesla VIN. It fails only for strings that cannot be
+// a VIN; a wrong check digit is reported in CheckDigitValid.
+func DecodeVIN(vin string) (VINInfo, error) {
+	vin = strings.ToUpper(strings.TrimSpace(vin))
+	info := VINInfo{VIN: vin}
+
+	if len(vin) != 17 {
+		return info, fmt.Errorf("VIN %q must have 17 characters", vin)
+	}
+	for _, r := range vin {
+		if _, ok := vinValue(r); !ok {
+			return info, fmt.Errorf("VIN %q contains the invalid character %q", vin, r)
+		}
+	}
+
+	info.Manufacturer = vinManufacturers[vin[:3]]
+	info.Model = vinModels[vin[3]]
+	info.Body = vinBodies[vin[4]]
+	info.BatteryCode, info.Battery = vin[6:7], vinBatteries[vin[6]]
+	info.MotorCode, info.Motor = vin[7:8], vinMotors[vin[7]]
+	info.ModelYear = vinModelYear(vin[9], time.Now().Year())
+	info.PlantCode, info.Plant = vin[10:11], vinPlants[vin[10]]
+	info.Serial = vin[11:]
+	info.CheckDigitValid = VINCheckDigit(vin) == vin[8]
+
+	return info, nil
+}
+
+// vinWeights are the position weights of the check digit calculation.
+var vinWeights = [17]int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+
+// VINCheckDigit computes the check digit of a 17-character VIN: the
+// weighted sum of the transliterated characters modulo 11, with 10 written
+// as 'X'.
+func VINCheckDigit(vin string) byte {
+	sum := 0
+	for i := 0; i < len(vin) && i < len(vinWeights); i++ {
+		value, _ := vinValue(rune(vin[i]))
+		sum += value * vinWeights[i]
+	}
+	if digit := sum % 11; digit < 10 {
+		return byte('0' + digit)
+	}
+	return 'X'
+}
+
+// vinValue transliterates a VIN character. I, O and Q are not allowed.
+func vinValue(r rune) (int, bool) {
+	switch {
+	case r >= '0' && r <= '9':
+		return int(r - '0'), true
+	case r >= 'A' && r <= 'H':
+		return int(r-'A') + 1, true
+	case r >= 'J' && r <= 'N':
+		return int(r-'J') + 1, true
+	case r == 'P':
+		return 7, true
+	case r == 'R':
+		return 9, true
+	case r >= 'S' && r <= 'Z':
+		return int(r-'S') + 2, true
+	}
+	return 0, false
+}
+
+// vinModelYear returns the most recent model year for code that is not
+// later than the year after currentYear, or 0 for an invalid code.
+func vinModelYear(code byte, currentYear int) int {
+	i := strings.IndexByte(vinYears, code)
+	if i < 0 {
+		return 0
+	}
+	year := 2010 + i
+	for year > currentYear+1 {
+		year -= len(vinYears)
+	}
+	for year+len(vinYears) <= currentYear+1 {
+		year += len(vinYears)
+	}
+	return year
+}
+
+// Summary describes the vehicle in one line, such as
+// "2025 Model Y, Dual motor, built in Berlin, serial 000001". It is always
+// English, for the notifiers; the GUI builds its own translated text.
+func (v VINInfo) Summary() string {
+	var parts []string
+
+	vehicle := v.Model
+	if vehicle == "" {
+		vehicle = "Tesla"
+	}
+	if v.ModelYear != 0 {
+		vehicle = fmt.Sprintf("%d %s", v.ModelYear, vehicle)
+	}
+	parts = append(parts, vehicle)
+
+	if v.Motor != "" {
+		parts = append(parts, v.Motor)
+	}
+	if v.Plant != "" {
+		parts = append(parts, "built in "+v.Plant)
+	}
+	parts = append(parts, "serial "+v.Serial)
+	if !v.CheckDigitValid {
+		parts = append(parts, "check digit does not match")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// AssignedVIN returns the VIN of a change that gives an order its first VIN.
+func (c Change) AssignedVIN() (string, bool) {
+	if c.Path != "order.vin" || (c.Kind != ChangeAdded && c.Kind != ChangeChanged) {
+		return "", false
+	}
+	if old, _ := c.OldValue.(string); old != "" {
+		return "", false
+	}
+	vin, _ := c.NewValue.(string)
+	return vin, vin != ""
+}
@@ -0,0 +1,92 @@
+package tesla
+
+import "testing"
+
+func TestDecodeVIN(t *testing.T) {
+	v, err := DecodeVIN(" xp7ygcek4sb000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := VINInfo{
+		VIN:             "XP7YGCEK4SB000001",
+		Manufacturer:    "Tesla Manufacturing Brandenburg (Germany)",
+		Model:           "Model Y",
+		Body:            "SUV 5-door, left-hand drive",
+		BatteryCode:     "E",
+		Battery:         "Electric, NMC battery",
+		MotorCode:       "K",
+		ModelYear:       2025,
+		PlantCode:       "B",
+		Plant:           "Berlin",
+		Serial:          "000001",
+		CheckDigitValid: true,
+	}
+	if v != want {
+		t.Errorf("DecodeVIN() = %+v, want %+v", v, want)
+	}
+	if got := v.Summary(); got != "2025 Model Y, built in Berlin, serial 000001" {
+		t.Errorf("Summary() = %q", got)
+	}
+}
+
+func TestDecodeVINCheckDigit(t *testing.T) {
+	// The worked example of 49 CFR 565, whose check digit is X.
+	if got := VINCheckDigit("1M8GDM9AXKP042788"); got != 'X' {
+		t.Errorf("VINCheckDigit() = %q, want 'X'", got)
+	}
+
+	v, err := DecodeVIN("XP7YGCEK5SB000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.CheckDigitValid {
+		t.Error("CheckDigitValid = true for a wrong check digit")
+	}
+}
+
+func TestDecodeVINInvalid(t *testing.T) {
+	for _, vin := range []string{"", "XP7YGCEK4SB00000", "XP7YGCEK4SB0000012", "XP7YGCEK4SBO00001"} {
+		if _, err := DecodeVIN(vin); err == nil {
+			t.Errorf("DecodeVIN(%q) succeeded", vin)
+		}
+	}
+}
+
+func TestVINModelYear(t *testing.T) {
+	tests := []struct {
+		code byte
+		now  int
+		want int
+	}{
+		{'S', 2025, 2025},
+		{'T', 2025, 2026},
+		{'Y', 2025, 2000},
+		{'A', 2040, 2040},
+		{'I', 2025, 0},
+	}
+	for _, tt := range tests {
+		if got := vinModelYear(tt.code, tt.now); got != tt.want {
+			t.Errorf("vinModelYear(%q, %d) = %d, want %d", tt.code, tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestChangeAssignedVIN(t *testing.T) {
+	tests := []struct {
+		change Change
+		want   string
+	}{
+		{Change{Path: "order.vin", Kind: ChangeAdded, NewValue: "XP7YGCEK4SB000001"}, "XP7YGCEK4SB000001"},
+		{Change{Path: "order.vin", Kind: ChangeChanged, OldValue: "", NewValue: "XP7YGCEK4SB000001"}, "XP7YGCEK4SB000001"},
+		{Change{Path: "order.vin", Kind: ChangeChanged, OldValue: "XP7YGCEK4SB000001", NewValue: "XP7YGCEK4SB000002"}, ""},
+		{Change{Path: "order.vin", Kind: ChangeRemoved, OldValue: "XP7YGCEK4SB000001"}, ""},
+		{Change{Path: "order.orderStatus", Kind: ChangeChanged, OldValue: "", NewValue: "BOOKED"}, ""},
+	}
+	for _, tt := range tests {
+		got, ok := tt.change.AssignedVIN()
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("AssignedVIN(%+v) = %q, %v", tt.change, got, ok)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package watch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/notify"
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+const DefaultInterval = 10 * time.Minute
+
+// MinInterval keeps the watcher from hammering the Tesla API.
+const MinInterval = time.Minute
+
+// Config is the watch daemon configuration, stored as JSON in ConfigFile.
+type Config struct {
+	Interval  Duration      `json:"interval"`
+	Notifiers notify.Config `json:"notifiers"`
+}
+
+// Duration is a time.Duration that is written as "10m" in JSON.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+// ConfigFile returns the default location of the watch configuration.
+func ConfigFile() string {
+	return filepath.Join(tesla.ConfigDir, "watch.json")
+}
+
+// DefaultConfig polls every DefaultInterval and only shows desktop
+// notifications.
+func DefaultConfig() Config {
+	return Config{
+		Interval: Duration(DefaultInterval),
+		Notifiers: notify.Config{
+			Desktop: notify.DesktopConfig{Enabled: true},
+		},
+	}
+}
+
+// LoadConfig reads the configuration at path. A missing file yields
+// DefaultConfig.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid watch config %s: %w", path, err)
+	}
+
+	if time.Duration(cfg.Interval) < MinInterval {
+		return cfg, fmt.Errorf("invalid watch config %s: interval must be at least %s", path, MinInterval)
+	}
+
+	return cfg, nil
+}
+
+// SaveConfig writes cfg to path.
+func SaveConfig(path string, cfg Config) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
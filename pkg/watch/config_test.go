@@ -0,0 +1,73 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigDefaults(t *testing.T) {
+	cfg, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Duration(cfg.Interval) != DefaultInterval {
+		t.Errorf("Interval = %s, want %s", time.Duration(cfg.Interval), DefaultInterval)
+	}
+	if !cfg.Notifiers.Desktop.Enabled || cfg.Notifiers.Webhook.Enabled || cfg.Notifiers.Email.Enabled || cfg.Notifiers.Command.Enabled {
+		t.Errorf("Notifiers = %+v, want only desktop", cfg.Notifiers)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watch.json")
+	if err := os.WriteFile(path, []byte(`{"interval":"5m","notifiers":{"webhook":{"enabled":true,"url":"http://localhost/hook"}}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Duration(cfg.Interval) != 5*time.Minute {
+		t.Errorf("Interval = %s, want 5m", time.Duration(cfg.Interval))
+	}
+	// Notifiers missing from the file keep their defaults.
+	if !cfg.Notifiers.Desktop.Enabled || !cfg.Notifiers.Webhook.Enabled {
+		t.Errorf("Notifiers = %+v, want desktop and webhook", cfg.Notifiers)
+	}
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"below minimum": `{"interval":"30s"}`,
+		"bad duration":  `{"interval":"often"}`,
+		"not a string":  `{"interval":600}`,
+	} {
+		path := filepath.Join(t.TempDir(), "watch.json")
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("%s: LoadConfig() accepted %s", name, data)
+		}
+	}
+}
+
+func TestSaveConfigRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watch.json")
+	cfg := DefaultConfig()
+	cfg.Interval = Duration(15 * time.Minute)
+
+	if err := SaveConfig(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Interval != cfg.Interval || loaded.Notifiers.Desktop != cfg.Notifiers.Desktop {
+		t.Errorf("LoadConfig() = %+v, want %+v", loaded, cfg)
+	}
+}
@@ -0,0 +1,132 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/notify"
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+// Target is an account whose orders are watched.
+type Target struct {
+	Account string
+	Manager *tesla.OrderManager
+}
+
+// Watcher periodically fetches the orders of its targets, compares them with
+// the saved snapshots and dispatches any changes to its notifiers.
+type Watcher struct {
+	Targets   []Target
+	Interval  time.Duration
+	Notifiers []notify.Notifier
+	Logger    *log.Logger
+}
+
+func NewWatcher(targets []Target, interval time.Duration, notifiers []notify.Notifier) *Watcher {
+	return &Watcher{
+		Targets:   targets,
+		Interval:  interval,
+		Notifiers: notifiers,
+		Logger:    log.Default(),
+	}
+}
+
+// Run polls immediately and then every Interval until ctx is cancelled. It
+// only returns early when none of the targets can be used without logging in
+// again.
+func (w *Watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.Poll(ctx); err != nil {
+			if errors.Is(err, errAllLoginRequired) {
+				return err
+			}
+			w.Logger.Printf("Error polling orders: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+var errAllLoginRequired = fmt.Errorf("%w for all watched accounts", tesla.ErrLoginRequired)
+
+// Poll runs a single fetch and compare cycle for every target and returns
+// the changes found. Errors of single targets do not stop the others.
+func (w *Watcher) Poll(ctx context.Context) ([]tesla.Change, error) {
+	var allChanges []tesla.Change
+	var errs []error
+	loginRequired := 0
+
+	for _, target := range w.Targets {
+		changes, err := w.pollTarget(ctx, target)
+		allChanges = append(allChanges, changes...)
+		if err != nil {
+			if errors.Is(err, tesla.ErrLoginRequired) {
+				loginRequired++
+			}
+			errs = append(errs, fmt.Errorf("account %s: %w", target.Account, err))
+		}
+	}
+
+	if len(w.Targets) > 0 && loginRequired == len(w.Targets) {
+		return allChanges, errors.Join(append(errs, errAllLoginRequired)...)
+	}
+
+	return allChanges, errors.Join(errs...)
+}
+
+func (w *Watcher) pollTarget(ctx context.Context, target Target) ([]tesla.Change, error) {
+	oldOrders, err := target.Manager.LoadOrdersFromFile()
+	if err != nil {
+		w.Logger.Printf("Error loading saved orders of account %s: %v", target.Account, err)
+	}
+
+	newOrders, err := target.Manager.GetDetailedOrders(ctx)
+	if err != nil && (newOrders == nil || errors.Is(err, tesla.ErrLoginRequired)) {
+		return nil, err
+	}
+	// Orders whose details failed are compared without their details, so
+	// the partial result is still worth saving and reporting.
+	detailsErr := err
+
+	var changes []tesla.Change
+	if len(oldOrders) > 0 {
+		changes = target.Manager.CompareOrders(oldOrders, newOrders)
+	}
+
+	w.Logger.Printf("Account %s: fetched %d orders, %d changes", target.Account, len(newOrders), len(changes))
+
+	// Notify before saving: a snapshot that cannot be written must not
+	// swallow the changes it would have recorded.
+	if len(changes) > 0 {
+		account := ""
+		if len(w.Targets) > 1 {
+			account = target.Account
+		}
+		w.dispatch(ctx, notify.NewEvent(account, changes))
+	}
+
+	if err := target.Manager.SaveOrdersToFile(newOrders); err != nil {
+		return changes, errors.Join(fmt.Errorf("saving orders: %w", err), detailsErr)
+	}
+
+	return changes, detailsErr
+}
+
+func (w *Watcher) dispatch(ctx context.Context, event notify.Event) {
+	for _, notifier := range w.Notifiers {
+		if err := notifier.Notify(ctx, event); err != nil {
+			w.Logger.Printf("Error sending %s notification: %v", notifier.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package watch
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tgezginis/tesla-tracking-app/pkg/notify"
+	"github.com/tgezginis/tesla-tracking-app/pkg/tesla"
+)
+
+// recordingNotifier keeps the events it is sent.
+type recordingNotifier struct {
+	events []notify.Event
+}
+
+func (n *recordingNotifier) Name() string { return "recording" }
+
+func (n *recordingNotifier) Notify(ctx context.Context, event notify.Event) error {
+	n.events = append(n.events, event)
+	return nil
+}
+
+// newTestTarget returns a target whose API reports a single order with the
+// given status and whose saved snapshot has it as BOOKED.
+func newTestTarget(t *testing.T, status string) Target {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/1/users/orders":
+			fmt.Fprintf(w, `{"response":[{"referenceNumber":"RN1","orderStatus":%q}]}`, status)
+		case "/tasks":
+			fmt.Fprint(w, `{"tasks":{}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	claims, _ := json.Marshal(map[string]interface{}{"exp": time.Now().Add(time.Hour).Unix()})
+	auth := tesla.NewTeslaAuth()
+	auth.Endpoints = tesla.EndpointsForBaseURL(server.URL)
+	auth.TokenFile = filepath.Join(dir, "tokens.json")
+	auth.TokenStore = &tesla.PlainFileStore{Path: auth.TokenFile}
+	auth.AccessToken = "e30." + base64.RawURLEncoding.EncodeToString(claims) + ".signature"
+
+	manager := tesla.NewOrderManager(auth)
+	manager.OrdersFile = filepath.Join(dir, "orders.json")
+	manager.HistoryFile = filepath.Join(dir, "history.jsonl")
+
+	previous := []tesla.DetailedOrder{{Order: tesla.Order{ReferenceNumber: "RN1", OrderStatus: "BOOKED"}}}
+	data, err := json.Marshal(previous)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(manager.OrdersFile, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return Target{Account: "Personal", Manager: manager}
+}
+
+func newTestWatcher(targets []Target, notifiers ...notify.Notifier) *Watcher {
+	w := NewWatcher(targets, DefaultInterval, notifiers)
+	w.Logger = log.New(io.Discard, "", 0)
+	return w
+}
+
+func TestPollNotifiesChanges(t *testing.T) {
+	notifier := &recordingNotifier{}
+	target := newTestTarget(t, "DELIVERED")
+	w := newTestWatcher([]Target{target}, notifier)
+
+	changes, err := w.Poll(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Path != "order.orderStatus" {
+		t.Fatalf("Poll() = %+v, want the status change", changes)
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Account != "" {
+		t.Errorf("events = %+v, want one without an account", notifier.events)
+	}
+
+	// The snapshot was saved, so polling again finds nothing new.
+	if changes, err := w.Poll(t.Context()); err != nil || len(changes) != 0 {
+		t.Errorf("second Poll() = %+v, %v", changes, err)
+	}
+	if len(notifier.events) != 1 {
+		t.Errorf("%d events after the second poll, want 1", len(notifier.events))
+	}
+}
+
+func TestPollNotifiesWhenSaveFails(t *testing.T) {
+	notifier := &recordingNotifier{}
+	target := newTestTarget(t, "DELIVERED")
+	// A directory cannot be appended to, so saving the snapshot fails.
+	target.Manager.HistoryFile = t.TempDir()
+	w := newTestWatcher([]Target{target}, notifier)
+
+	changes, err := w.Poll(t.Context())
+	if err == nil {
+		t.Error("Poll() succeeded although the orders could not be saved")
+	}
+	if len(changes) != 1 || len(notifier.events) != 1 {
+		t.Errorf("Poll() = %d changes, %d events, want 1 each", len(changes), len(notifier.events))
+	}
+}